@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// tables whose indexes and planner statistics are rebuilt by POST /admin/reindex
+var reindexTables = []string{"movies", "movie_ratings", "movie_reviews", "movie_tags", "movie_views"}
+
+// reindex refreshes the derived data that can drift after large imports: every materialized
+// view, the indexes and planner statistics of the catalogue tables, and the stats cache.
+// REINDEX blocks writes to each table while it runs, so this is meant for quiet periods.
+func reindex(c *gin.Context) {
+	started := time.Now()
+
+	rows, err := db.Query("SELECT schemaname, matviewname FROM pg_matviews ORDER BY schemaname, matviewname")
+	if err != nil {
+		log.Printf("Error listing materialized views: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reindex", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+	views := []string{}
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			log.Printf("Error scanning materialized view: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reindex", "details": err.Error()})
+			return
+		}
+		views = append(views, pq.QuoteIdentifier(schema)+"."+pq.QuoteIdentifier(name))
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reindex", "details": err.Error()})
+		return
+	}
+
+	for _, view := range views {
+		if _, err := db.Exec("REFRESH MATERIALIZED VIEW " + view); err != nil {
+			log.Printf("Error refreshing materialized view %s: %v", view, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh " + view, "details": err.Error()})
+			return
+		}
+	}
+	for _, table := range reindexTables {
+		if _, err := db.Exec(fmt.Sprintf("REINDEX TABLE %[1]s; ANALYZE %[1]s", table)); err != nil {
+			log.Printf("Error reindexing %s: %v", table, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reindex " + table, "details": err.Error()})
+			return
+		}
+	}
+	statsCache.invalidate()
+
+	c.JSON(http.StatusOK, gin.H{
+		"materializedViews": views,
+		"reindexedTables":   reindexTables,
+		"statsCacheCleared": true,
+		"durationMs":        time.Since(started).Milliseconds(),
+	})
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+// maximum number of IDs accepted by a single batch request
+const maxBatchIDs = 500
+
+// maximum number of movies that can be compared side by side
+const maxCompareIDs = 10
+
+// request body for batch operations addressed by movie ID
+type BatchIDsInput struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// validateBatchIDs checks that a batch ID list is non-empty and within maxBatchIDs
+func validateBatchIDs(ids []int) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("ids must contain at least one movie ID")
+	}
+	if len(ids) > maxBatchIDs {
+		return fmt.Errorf("ids may contain at most %d movie IDs", maxBatchIDs)
+	}
+	return nil
+}
+
+// batchDeleteMovies deletes every movie in the given ID list in a single statement
+func batchDeleteMovies(c *gin.Context) {
+	var input BatchIDsInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateBatchIDs(input.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Query("DELETE FROM movies WHERE id = ANY($1) RETURNING id", pq.Array(input.IDs))
+	if err != nil {
+		log.Printf("Error batch deleting movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete movies", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	deleted := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning deleted movie ID: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read deleted movies", "details": err.Error()})
+			return
+		}
+		deleted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read deleted movies", "details": err.Error()})
+		return
+	}
+
+	notFound := []int{}
+	seen := map[int]bool{}
+	for _, id := range input.IDs {
+		if !deleted[id] && !seen[id] {
+			notFound = append(notFound, id)
+		}
+		if deleted[id] && !seen[id] {
+			movieEvents.publish(movieDeleted, id)
+		}
+		seen[id] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": len(deleted), "notFound": notFound})
+}
+
+// fetchMoviesInOrder loads the given movies in one query and returns them in the requested
+// order, along with the requested IDs that don't exist. Repeated IDs are returned once.
+func fetchMoviesInOrder(ids []int) ([]Movie, []int, error) {
+	found, err := queryMovies(fmt.Sprintf("SELECT %s FROM movies WHERE id = ANY($1)", movieColumns), pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[int]Movie, len(found))
+	for _, movie := range found {
+		byID[movie.ID] = movie
+	}
+
+	movies := []Movie{}
+	notFound := []int{}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if movie, ok := byID[id]; ok {
+			movies = append(movies, movie)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+	return movies, notFound, nil
+}
+
+// parseIDList parses a comma-separated list of movie IDs such as "1,2,3"
+func parseIDList(raw string) ([]int, error) {
+	ids := []int{}
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("ids must be a comma-separated list of movie IDs")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// compareMovies returns several movies side by side in the order requested via ?ids=1,2,3
+func compareMovies(c *gin.Context) {
+	raw := c.Query("ids")
+	if raw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids query parameter is required"})
+		return
+	}
+	ids, err := parseIDList(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) > maxCompareIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d movies can be compared", maxCompareIDs)})
+		return
+	}
+
+	movies, notFound, err := fetchMoviesInOrder(ids)
+	if err != nil {
+		log.Printf("Error fetching movies to compare: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies", "details": err.Error()})
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"movies": movies, "notFound": notFound})
+}
+
+// getMoviesByIDs returns up to maxBatchIDs movies in the order their IDs are posted, for
+// rendering ranked lists such as recommendations. Repeated IDs are returned once.
+func getMoviesByIDs(c *gin.Context) {
+	var input BatchIDsInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateBatchIDs(input.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movies, notFound, err := fetchMoviesInOrder(input.IDs)
+	if err != nil {
+		log.Printf("Error fetching movies by ID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies", "details": err.Error()})
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"movies": movies, "notFound": notFound})
+}
+
+// request body for setting one rating on many movies
+type BulkRatingInput struct {
+	IDs    []int `json:"ids" binding:"required"`
+	Rating *int  `json:"rating" binding:"required"`
+}
+
+// bulkUpdateRating sets the same rating on every movie in the ID list
+func bulkUpdateRating(c *gin.Context) {
+	var input BulkRatingInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateBatchIDs(input.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRating(*input.Rating); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Query("UPDATE movies SET rating = $1, version = version + 1 WHERE id = ANY($2) RETURNING id", *input.Rating, pq.Array(input.IDs))
+	if err != nil {
+		log.Printf("Error bulk updating ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ratings", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	updated := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning updated movie ID: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+			return
+		}
+		updated = append(updated, id)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieUpdated, updated...)
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(updated), "rating": *input.Rating})
+}
+
+// one entry of a batch update: the movie ID plus the same partial fields as PUT /movies/:id
+type BatchUpdateItem struct {
+	ID int `json:"id" binding:"required"`
+	UpdateMovieInput
+}
+
+// batchUpdateMovies applies a list of partial updates in a single transaction.
+// Every item must succeed for any of them to be kept; on the first failure the
+// transaction is rolled back and the offending item is reported.
+func batchUpdateMovies(c *gin.Context) {
+	// decoded by hand rather than through bindJSON so validation errors can name the failing item
+	var items []BatchUpdateItem
+	if err := json.NewDecoder(c.Request.Body).Decode(&items); err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload", "details": err.Error()})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Batch must contain at least one update"})
+		return
+	}
+	if len(items) > maxBatchIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Batch may contain at most %d updates", maxBatchIDs)})
+		return
+	}
+
+	for i := range items {
+		if err := binding.Validator.ValidateStruct(&items[i]); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"index": i, "errors": validationFields(&items[i], validationErrs)}))
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "index": i})
+			return
+		}
+		if items[i].Version == nil {
+			c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"index": i, "errors": map[string]string{"version": "required"}}))
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting batch update transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movies", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	results := make([]gin.H, 0, len(items))
+	for i := range items {
+		item := &items[i]
+		// titles are checked inside the transaction, so clashes with earlier items in the batch are caught too
+		newVersion, updateErr := applyMovieUpdate(tx, item.ID, &item.UpdateMovieInput, nil)
+		if updateErr != nil {
+			failed := gin.H{"index": i, "id": item.ID}
+			for k, v := range localizeError(c, updateErr.body) {
+				failed[k] = v
+			}
+			c.JSON(updateErr.status, gin.H{"error": "Batch update failed, no changes were applied", "failed": failed})
+			return
+		}
+		results = append(results, gin.H{"index": i, "id": item.ID, "version": newVersion})
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing batch update: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movies", "details": err.Error()})
+		return
+	}
+	for _, item := range items {
+		movieEvents.publish(movieUpdated, item.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(results), "results": results})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// a stored response body and when it stops being served
+type cachedResponse struct {
+	body        []byte
+	contentType string
+	expires     time.Time
+}
+
+// responseCache keeps successful GET responses in memory for ttl, keyed by request URI and
+// Accept header, since some responses are negotiated.
+// Any write to the catalogue clears it, so cached figures are never staler than the last write.
+type responseCache struct {
+	ttl        time.Duration
+	mu         sync.Mutex
+	entries    map[string]cachedResponse
+	generation int // bumped on every invalidation so in-flight responses from before it aren't stored
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+}
+
+// statsCache fronts the stats endpoints; it is created by setupRouter
+var statsCache *responseCache
+
+// statsCacheTTL is how long stats responses are reused (STATS_CACHE_TTL, default 30s)
+func statsCacheTTL() time.Duration {
+	return envDuration("STATS_CACHE_TTL", 30*time.Second)
+}
+
+func (rc *responseCache) get(key string) (cachedResponse, int, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		ok = false
+	}
+	return entry, rc.generation, ok
+}
+
+func (rc *responseCache) set(key string, generation int, entry cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if generation != rc.generation {
+		return
+	}
+	now := time.Now()
+	for k, e := range rc.entries {
+		if now.After(e.expires) {
+			delete(rc.entries, k)
+		}
+	}
+	rc.entries[key] = entry
+}
+
+func (rc *responseCache) invalidate() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = map[string]cachedResponse{}
+	rc.generation++
+}
+
+// bodyRecorder copies the response body aside as it is written
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body []byte
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body = append(w.body, data...)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// cached serves responses from rc while they are fresh and stores successful ones.
+// Cache-Control advertises the same TTL to clients.
+func (rc *responseCache) cached() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(rc.ttl.Seconds())))
+		c.Writer.Header().Add("Vary", "Accept")
+		key := c.Request.URL.RequestURI() + " " + c.GetHeader("Accept")
+		entry, generation, ok := rc.get(key)
+		if ok {
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, entry.contentType, entry.body)
+			c.Abort()
+			return
+		}
+
+		c.Header("X-Cache", "MISS")
+		w := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.Status() == http.StatusOK {
+			rc.set(key, generation, cachedResponse{body: w.body, contentType: w.Header().Get("Content-Type"), expires: time.Now().Add(rc.ttl)})
+		}
+	}
+}
+
+// invalidateOnWrite clears rc after every successful mutating request
+func invalidateOnWrite(rc *responseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if isReadOnlyPost(c) {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.Writer.Status() < http.StatusBadRequest {
+				rc.invalidate()
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maximum collection name length, matching the VARCHAR(255) column
+const maxCollectionNameLength = 255
+
+// a franchise such as "The Lord of the Rings"; a movie belongs to at most one
+type Collection struct {
+	ID        int       `json:"id" xml:"id"`
+	Name      string    `json:"name" xml:"name" binding:"required"`
+	CreatedAt time.Time `json:"createdAt" xml:"createdAt"`
+}
+
+// collectionParam loads the collection named by the :id path parameter, answering
+// 400 or 404 itself when it is malformed or names no collection
+func collectionParam(c *gin.Context) (Collection, bool) {
+	var collection Collection
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return collection, false
+	}
+	err = db.QueryRow("SELECT id, name, created_at FROM collections WHERE id = $1", id).
+		Scan(&collection.ID, &collection.Name, &collection.CreatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, apiError(c, codeCollectionNotFound))
+		return collection, false
+	}
+	if err != nil {
+		log.Printf("Error fetching collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection", "details": err.Error()})
+		return collection, false
+	}
+	return collection, true
+}
+
+// createCollection adds a named collection; names are unique
+func createCollection(c *gin.Context) {
+	var collection Collection
+	if !bindJSON(c, &collection) {
+		return
+	}
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+	if utf8.RuneCountInString(collection.Name) > maxCollectionNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name must be at most %d characters", maxCollectionNameLength)})
+		return
+	}
+
+	err := db.QueryRow("INSERT INTO collections (name) VALUES ($1) ON CONFLICT (name) DO NOTHING RETURNING id, created_at",
+		collection.Name).Scan(&collection.ID, &collection.CreatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusConflict, gin.H{"error": "A collection with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// getCollections lists every collection by name
+func getCollections(c *gin.Context) {
+	rows, err := db.Query("SELECT id, name, created_at FROM collections ORDER BY name, id")
+	if err != nil {
+		log.Printf("Error fetching collections: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	collections := []Collection{}
+	for rows.Next() {
+		var collection Collection
+		if err := rows.Scan(&collection.ID, &collection.Name, &collection.CreatedAt); err != nil {
+			log.Printf("Error scanning collection row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan collections", "details": err.Error()})
+			return
+		}
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collections", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// getCollectionMovies lists a collection's movies in release order
+func getCollectionMovies(c *gin.Context) {
+	collection, ok := collectionParam(c)
+	if !ok {
+		return
+	}
+
+	movies, err := queryMovies(fmt.Sprintf("SELECT %s FROM movies WHERE collection_id = $1 ORDER BY year, title, id", movieColumns), collection.ID)
+	if err != nil {
+		log.Printf("Error fetching collection movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection movies", "details": err.Error()})
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"collection": collection, "movies": movies})
+}
+
+// addCollectionMovies puts every listed movie into the collection, moving it out of any
+// other collection. IDs that don't exist are reported back.
+func addCollectionMovies(c *gin.Context) {
+	collection, ok := collectionParam(c)
+	if !ok {
+		return
+	}
+	var input BatchIDsInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateBatchIDs(input.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// movies already in the collection are left untouched so their version doesn't move
+	rows, err := db.Query(`UPDATE movies SET collection_id = $1, version = version + 1
+		WHERE id = ANY($2) AND collection_id IS DISTINCT FROM $1 RETURNING id`, collection.ID, pq.Array(input.IDs))
+	if err != nil {
+		log.Printf("Error adding movies to collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add movies to collection", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	added := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning updated movie ID: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+			return
+		}
+		added = append(added, id)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieUpdated, added...)
+
+	found := map[int]bool{}
+	existing, err := db.Query("SELECT id FROM movies WHERE id = ANY($1)", pq.Array(input.IDs))
+	if err != nil {
+		log.Printf("Error fetching collection movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+		return
+	}
+	defer existing.Close()
+	for existing.Next() {
+		var id int
+		if err := existing.Scan(&id); err != nil {
+			log.Printf("Error scanning movie ID: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+			return
+		}
+		found[id] = true
+	}
+	if err := existing.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check update status", "details": err.Error()})
+		return
+	}
+
+	notFound := []int{}
+	seen := map[int]bool{}
+	for _, id := range input.IDs {
+		if !found[id] && !seen[id] {
+			notFound = append(notFound, id)
+		}
+		seen[id] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "added": len(added), "notFound": notFound})
+}
+
+// removeCollectionMovie takes a movie out of the collection; it is a 404 if the movie
+// isn't in it
+func removeCollectionMovie(c *gin.Context) {
+	collection, ok := collectionParam(c)
+	if !ok {
+		return
+	}
+	movieID, err := strconv.Atoi(c.Param("movieId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	result, err := db.Exec("UPDATE movies SET collection_id = NULL, version = version + 1 WHERE id = $1 AND collection_id = $2",
+		movieID, collection.ID)
+	if err != nil {
+		log.Printf("Error removing movie from collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove movie from collection", "details": err.Error()})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie is not in this collection"})
+		return
+	}
+	movieEvents.publish(movieUpdated, movieID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Movie removed from collection"})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriter holds back the start of a response until it reaches minSize bytes, then
+// switches to gzip. Responses that finish smaller are written uncompressed, since the
+// gzip framing would outweigh the savings.
+type gzipWriter struct {
+	gin.ResponseWriter
+	level   int
+	minSize int
+	buf     []byte
+	gz      *gzip.Writer
+	plain   bool // decided against compressing
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.plain:
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush compresses whatever has been buffered so far; a handler that flushes is
+// streaming, so the response is taken to be large.
+func (w *gzipWriter) Flush() {
+	if w.gz == nil && !w.plain {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start switches the response to gzip and writes out the buffer
+func (w *gzipWriter) start() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		// the handler already encoded the body itself
+		w.plain = true
+	} else {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		// the compressed body is no longer byte-identical to what a strong ETag promises
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+		if err != nil {
+			return err
+		}
+		w.gz = gz
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := w.Write(buf)
+	return err
+}
+
+// finish writes out a response that never reached minSize, or closes the gzip stream
+func (w *gzipWriter) finish() {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			log.Printf("Error closing gzip stream: %v", err)
+		}
+		return
+	}
+	if len(w.buf) > 0 {
+		w.plain = true
+		w.ResponseWriter.Write(w.buf)
+	}
+}
+
+// gzipMiddleware compresses responses of at least minSize bytes for clients that accept gzip
+func gzipMiddleware(level, minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, level: level, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
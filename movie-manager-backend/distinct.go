@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// default and maximum number of values returned by the distinct-value endpoints
+const (
+	defaultDistinctLimit = 100
+	maxDistinctLimit     = 500
+)
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+// Patterns built from it are used with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// containsPattern is a LIKE pattern matching s literally anywhere in the value
+func containsPattern(s string) string {
+	return "%" + escapeLike(s) + "%"
+}
+
+// distinctQuery narrows a distinct-values listing: prefix is matched case-insensitively,
+// and limit/offset page through the sorted values
+type distinctQuery struct {
+	prefix        string
+	limit, offset int
+}
+
+// parseDistinctQuery reads q, limit and offset from the request
+func parseDistinctQuery(c *gin.Context) distinctQuery {
+	query := distinctQuery{prefix: strings.TrimSpace(c.Query("q")), limit: defaultDistinctLimit}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		query.limit = min(limit, maxDistinctLimit)
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		query.offset = offset
+	}
+	return query
+}
+
+// distinctValues returns one page of the sorted, non-empty distinct values of a column
+// along with how many there are in total. column must be a trusted identifier, never user input.
+func distinctValues(column string, query distinctQuery) ([]string, int, error) {
+	where := fmt.Sprintf("NULLIF(TRIM(%s::text), '') IS NOT NULL", column)
+	args := []interface{}{}
+	if query.prefix != "" {
+		args = append(args, escapeLike(query.prefix)+"%")
+		where += fmt.Sprintf(` AND TRIM(%s::text) ILIKE $1 ESCAPE '\'`, column)
+	}
+
+	var total int
+	err := db.QueryRow(fmt.Sprintf("SELECT COUNT(DISTINCT TRIM(%s::text)) FROM movies WHERE %s", column, where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.offset, query.limit)
+	rows, err := db.Query(fmt.Sprintf(
+		"SELECT DISTINCT TRIM(%s::text) AS value FROM movies WHERE %s ORDER BY value OFFSET $%d LIMIT $%d",
+		column, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, 0, err
+		}
+		values = append(values, value)
+	}
+	return values, total, rows.Err()
+}
+
+// distinctHandler serves the distinct values of column under key, e.g. {"genres": [...]}
+func distinctHandler(column, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := parseDistinctQuery(c)
+		values, total, err := distinctValues(column, query)
+		if err != nil {
+			log.Printf("Error fetching %s: %v", key, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + key, "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{key: values, "total": total, "limit": query.limit, "offset": query.offset})
+	}
+}
+
+// getYears lists every release year present in the catalogue; q matches leading digits
+func getYears(c *gin.Context) {
+	query := parseDistinctQuery(c)
+	values, total, err := distinctValues("year", query)
+	if err != nil {
+		log.Printf("Error fetching years: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch years", "details": err.Error()})
+		return
+	}
+
+	years := make([]int, 0, len(values))
+	for _, value := range values {
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		years = append(years, year)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"years": years, "total": total, "limit": query.limit, "offset": query.offset})
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Alien", "Alien"},
+		{"100% Wolf", `100\% Wolf`},
+		{"The_Thing", `The\_Thing`},
+		{`AC\DC`, `AC\\DC`},
+		{`50%_off\`, `50\%\_off\\`},
+		{`\%`, `\\\%`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	if got, want := containsPattern("100%"), `%100\%%`; got != want {
+		t.Errorf("containsPattern(%q) = %q, want %q", "100%", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actions reported in MovieEvent
+const (
+	movieCreated = "created"
+	movieUpdated = "updated"
+	movieDeleted = "deleted"
+)
+
+// how many events a subscriber may fall behind before it is disconnected
+const eventBufferSize = 64
+
+// interval between keep-alive comments on an idle stream, so proxies don't close it
+const streamHeartbeat = 30 * time.Second
+
+// a change to a movie, pushed to GET /movies/stream subscribers
+type MovieEvent struct {
+	Action string `json:"action"`
+	ID     int    `json:"id"`
+}
+
+// eventHub fans movie events out to every subscribed channel
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan MovieEvent]struct{}
+}
+
+// movieEvents receives an event from every handler that creates, updates or deletes movies
+var movieEvents = &eventHub{subscribers: map[chan MovieEvent]struct{}{}}
+
+func (h *eventHub) subscribe() chan MovieEvent {
+	ch := make(chan MovieEvent, eventBufferSize)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe removes and closes ch; it is a no-op if publish already dropped it
+func (h *eventHub) unsubscribe(ch chan MovieEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish sends one event per ID without blocking. A subscriber whose buffer is full is
+// dropped rather than stalling the writer; its stream ends and the client can reconnect
+// and catch up through GET /movies/changes.
+func (h *eventHub) publish(action string, ids ...int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range ids {
+		for ch := range h.subscribers {
+			select {
+			case ch <- MovieEvent{Action: action, ID: id}:
+			default:
+				delete(h.subscribers, ch)
+				close(ch)
+			}
+		}
+	}
+}
+
+// streamMovies pushes a Server-Sent Event named after the action for every movie
+// created, updated or deleted while the client stays connected
+func streamMovies(c *gin.Context) {
+	events := movieEvents.subscribe()
+	defer movieEvents.unsubscribe(events)
+
+	// the stream is meant to outlive SERVER_WRITE_TIMEOUT
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Warning: could not lift the write deadline for a movie stream: %v", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Action, event)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
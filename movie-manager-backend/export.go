@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportMoviesJSON streams every movie matching the list filters as a JSON array download.
+// Pagination parameters are ignored. Rows are encoded one at a time so memory use stays
+// flat regardless of catalogue size.
+func exportMoviesJSON(c *gin.Context) {
+	filter, err := parseMovieFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	querySQL := fmt.Sprintf("SELECT %s FROM movies %s ORDER BY id", movieColumns, filter.whereSQL())
+
+	rows, err := db.Query(querySQL, filter.args...)
+	if err != nil {
+		log.Printf("Error exporting movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export movies", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="movies.json"`)
+	c.Status(http.StatusOK)
+
+	// headers are already sent once streaming starts, so later failures can only be logged
+	encoder := json.NewEncoder(c.Writer)
+	c.Writer.WriteString("[")
+	first := true
+	for rows.Next() {
+		var movie Movie
+		if err := scanMovie(rows, &movie); err != nil {
+			log.Printf("Error scanning movie row during export: %v", err)
+			return
+		}
+		if !first {
+			c.Writer.WriteString(",")
+		}
+		first = false
+		if err := encoder.Encode(movie); err != nil {
+			log.Printf("Error writing movie during export: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows during export: %v", err)
+		return
+	}
+	c.Writer.WriteString("]")
+}
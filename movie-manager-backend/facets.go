@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// number of filtered movies sharing one value of a facet; Value is nil for movies without a year
+type FacetCount struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+// a facet groups the filtered movies by expr. Its own filters (exclude) are left out
+// so the counts show what selecting another value would return.
+type facetDimension struct {
+	name    string
+	expr    string
+	orderBy string
+	numeric bool
+	exclude []string
+}
+
+// movies without a genre are counted under noGenreFilter, which can be passed straight back as genre
+var facetDimensions = []facetDimension{
+	{"genre", fmt.Sprintf("COALESCE(NULLIF(TRIM(genre), ''), '%s')", noGenreFilter), "COUNT(*) DESC, value", false, []string{"genre", "hasGenre"}},
+	{"year", "year", "value DESC NULLS LAST", true, []string{"year", "hasYear"}},
+	{"rating", "rating", "value DESC NULLS LAST", true, []string{"hasRating", "unrated"}},
+}
+
+// queryFacet counts the movies matching filter for each value of the dimension
+func queryFacet(dim facetDimension, filter *movieFilter) ([]FacetCount, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT %s AS value, COUNT(*) FROM movies %s GROUP BY value ORDER BY %s",
+		dim.expr, filter.whereSQL(), dim.orderBy), filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []FacetCount{}
+	for rows.Next() {
+		var fc FacetCount
+		if dim.numeric {
+			var value sql.NullInt64
+			if err := rows.Scan(&value, &fc.Count); err != nil {
+				return nil, err
+			}
+			if value.Valid {
+				fc.Value = value.Int64
+			}
+		} else {
+			var value string
+			if err := rows.Scan(&value, &fc.Count); err != nil {
+				return nil, err
+			}
+			fc.Value = value
+		}
+		counts = append(counts, fc)
+	}
+	return counts, rows.Err()
+}
+
+// getMovieFacets returns genre, year and rating counts for a faceted search sidebar.
+// It takes the same filters as GET /movies; each facet is computed with every filter
+// applied except its own, and total is the count with all of them applied.
+func getMovieFacets(c *gin.Context) {
+	filter, err := parseMovieFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	total, err := countMovies(filter)
+	if err != nil {
+		log.Printf("Error counting movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch facets", "details": err.Error()})
+		return
+	}
+
+	facets := gin.H{}
+	for _, dim := range facetDimensions {
+		dimFilter, err := parseMovieFilters(c, dim.exclude...)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		counts, err := queryFacet(dim, dimFilter)
+		if err != nil {
+			log.Printf("Error fetching %s facet: %v", dim.name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch facets", "details": err.Error()})
+			return
+		}
+		facets[dim.name] = counts
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": total, "facets": facets, "appliedFilters": filter.applied})
+}
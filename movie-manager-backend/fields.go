@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a movie field that can be requested through ?fields=; expr selects it in the same
+// shape the full movie payload uses (NULL text as "", averageRating with its fallback)
+type movieField struct {
+	key  string
+	expr string
+}
+
+var movieFields = []movieField{
+	{"id", "id"},
+	{"title", "title"},
+	{"originalTitle", "COALESCE(original_title, '')"},
+	{"genre", "COALESCE(genre, '')"},
+	{"year", "year"},
+	{"rating", "rating"},
+	{"favorite", "favorite"},
+	{"watched", "watched"},
+	{"archived", "archived"},
+	{"language", "COALESCE(language, '')"},
+	{"country", "COALESCE(country, '')"},
+	{"runtimeMinutes", "COALESCE(runtime_minutes, 0)"},
+	{"posterUrl", "COALESCE(poster_url, '')"},
+	{"description", "COALESCE(description, '')"},
+	{"version", "version"},
+	{"createdAt", "created_at"},
+	{"updatedAt", "updated_at"},
+	{"favoritedAt", "favorited_at"},
+	{"averageRating", "COALESCE(rating_average, rating)::float8"},
+	{"ratingCount", "rating_count"},
+	{"reviewCount", "review_count"},
+	{"collectionId", "collection_id"},
+	{"collection", "(SELECT name FROM collections WHERE collections.id = movies.collection_id)"},
+}
+
+// parseMovieFields resolves a comma-separated field list. Names may be given as the JSON
+// key (posterUrl) or the column name (poster_url); unknown names are an error.
+func parseMovieFields(raw string) ([]movieField, error) {
+	fields := []movieField{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		normalized := strings.ReplaceAll(strings.ToLower(name), "_", "")
+		var match *movieField
+		for i := range movieFields {
+			if strings.ToLower(movieFields[i].key) == normalized {
+				match = &movieFields[i]
+				break
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		if !seen[match.key] {
+			seen[match.key] = true
+			fields = append(fields, *match)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// fieldSelectList returns the SELECT list for the given fields
+func fieldSelectList(fields []movieField) string {
+	exprs := make([]string, len(fields))
+	for i, f := range fields {
+		exprs[i] = f.expr
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// queryMovieFields runs a query selecting fieldSelectList(fields) and returns each row
+// as an object holding just those fields
+func queryMovieFields(query string, fields []movieField, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(fields))
+		dest := make([]interface{}, len(fields))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		movie := make(map[string]interface{}, len(fields))
+		for i, f := range fields {
+			// text can come back from the driver as raw bytes
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			movie[f.key] = values[i]
+		}
+		movies = append(movies, movie)
+	}
+	return movies, rows.Err()
+}
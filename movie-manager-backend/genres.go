@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// canonical genre names; EXTRA_GENRES (comma-separated) adds more
+var canonicalGenres = []string{
+	"Action", "Adventure", "Animation", "Biography", "Comedy", "Crime", "Documentary",
+	"Drama", "Family", "Fantasy", "History", "Horror", "Music", "Musical", "Mystery",
+	"Romance", "Sci-Fi", "Sport", "Thriller", "War", "Western",
+}
+
+// common spellings mapped onto canonical genres, keyed by genreKey
+var genreAliases = map[string]string{
+	"scifi":           "Sci-Fi",
+	"sciencefiction":  "Sci-Fi",
+	"sf":              "Sci-Fi",
+	"animated":        "Animation",
+	"anime":           "Animation",
+	"cartoon":         "Animation",
+	"romcom":          "Romance",
+	"romantic":        "Romance",
+	"biopic":          "Biography",
+	"docu":            "Documentary",
+	"documentaries":   "Documentary",
+	"scary":           "Horror",
+	"suspense":        "Thriller",
+	"sports":          "Sport",
+	"historical":      "History",
+	"crimedrama":      "Crime",
+	"comedies":        "Comedy",
+	"dramas":          "Drama",
+	"musicals":        "Musical",
+	"westerns":        "Western",
+	"actionadventure": "Action",
+}
+
+// genreKey folds a genre to lowercase letters and digits so "Sci-Fi", "sci fi" and "SciFi" compare equal
+func genreKey(genre string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(genre) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// allowedGenres returns the canonical genres including any configured via EXTRA_GENRES
+func allowedGenres() []string {
+	return append(append([]string{}, canonicalGenres...), envList("EXTRA_GENRES", nil)...)
+}
+
+// strictGenres reports whether STRICT_GENRES limits genres to allowedGenres
+func strictGenres() bool {
+	strict, _ := strconv.ParseBool(os.Getenv("STRICT_GENRES"))
+	return strict
+}
+
+// normalizeGenre maps known genres and aliases onto their canonical spelling. Unknown genres
+// are kept as given unless STRICT_GENRES is set, in which case they are rejected.
+func normalizeGenre(genre string) (string, error) {
+	genre = strings.TrimSpace(genre)
+	if genre == "" {
+		return "", nil
+	}
+
+	key := genreKey(genre)
+	allowed := allowedGenres()
+	for _, canonical := range allowed {
+		if genreKey(canonical) == key {
+			return canonical, nil
+		}
+	}
+	if canonical, ok := genreAliases[key]; ok {
+		return canonical, nil
+	}
+
+	if strictGenres() {
+		return "", fmt.Errorf("Unknown genre %q; allowed genres are: %s", genre, strings.Join(allowed, ", "))
+	}
+	return genre, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// one recorded change to a movie. Changes holds the whole row for create and
+// delete entries, and only the changed columns for updates.
+type MovieHistoryEntry struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes"`
+	ChangedAt time.Time       `json:"changedAt"`
+}
+
+// getMovieHistory lists the recorded changes for a movie, oldest first.
+// History outlives the movie itself, so a deleted movie still reports its entries.
+func getMovieHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	rows, err := db.Query("SELECT id, action, changes, changed_at FROM movie_history WHERE movie_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Error fetching movie history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie history", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	history := []MovieHistoryEntry{}
+	for rows.Next() {
+		var entry MovieHistoryEntry
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.Action, &changes, &entry.ChangedAt); err != nil {
+			log.Printf("Error scanning history row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read movie history", "details": err.Error()})
+			return
+		}
+		entry.Changes = changes
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read movie history", "details": err.Error()})
+		return
+	}
+
+	// movies created before history was recorded have no entries yet
+	if len(history) == 0 {
+		if _, err := fetchMovie(id); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+				return
+			}
+			log.Printf("Error fetching movie: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "history": history})
+}
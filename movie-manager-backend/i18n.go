@@ -0,0 +1,146 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// machine-readable codes for the error messages that are translated
+const (
+	codeInvalidMovieID     = "invalid_movie_id"
+	codeMovieNotFound      = "movie_not_found"
+	codeValidationFailed   = "validation_failed"
+	codeTitleTaken         = "title_taken"
+	codeEditConflict       = "edit_conflict"
+	codeInvalidReviewID    = "invalid_review_id"
+	codeReviewNotFound     = "review_not_found"
+	codePresetNotFound     = "preset_not_found"
+	codeCollectionNotFound = "collection_not_found"
+)
+
+// fallbackLanguage is used when the client accepts none of the translated languages
+const fallbackLanguage = "en"
+
+// errorMessages holds each error code's message per language; every code must have an English entry
+var errorMessages = map[string]map[string]string{
+	"en": {
+		codeInvalidMovieID:     "Invalid movie ID",
+		codeMovieNotFound:      "Movie not found",
+		codeValidationFailed:   "Validation failed",
+		codeTitleTaken:         "Movie with this title already exists",
+		codeEditConflict:       "Movie was modified by another request",
+		codeInvalidReviewID:    "Invalid review ID",
+		codeReviewNotFound:     "Review not found",
+		codePresetNotFound:     "Preset not found",
+		codeCollectionNotFound: "Collection not found",
+	},
+	"es": {
+		codeInvalidMovieID:     "ID de película no válido",
+		codeMovieNotFound:      "Película no encontrada",
+		codeValidationFailed:   "La validación ha fallado",
+		codeTitleTaken:         "Ya existe una película con este título",
+		codeEditConflict:       "Otra solicitud modificó la película",
+		codeInvalidReviewID:    "ID de reseña no válido",
+		codeReviewNotFound:     "Reseña no encontrada",
+		codePresetNotFound:     "Filtro guardado no encontrado",
+		codeCollectionNotFound: "Colección no encontrada",
+	},
+	"fr": {
+		codeInvalidMovieID:     "Identifiant de film invalide",
+		codeMovieNotFound:      "Film introuvable",
+		codeValidationFailed:   "La validation a échoué",
+		codeTitleTaken:         "Un film portant ce titre existe déjà",
+		codeEditConflict:       "Le film a été modifié par une autre requête",
+		codeInvalidReviewID:    "Identifiant de critique invalide",
+		codeReviewNotFound:     "Critique introuvable",
+		codePresetNotFound:     "Filtre enregistré introuvable",
+		codeCollectionNotFound: "Collection introuvable",
+	},
+	"de": {
+		codeInvalidMovieID:     "Ungültige Film-ID",
+		codeMovieNotFound:      "Film nicht gefunden",
+		codeValidationFailed:   "Validierung fehlgeschlagen",
+		codeTitleTaken:         "Ein Film mit diesem Titel existiert bereits",
+		codeEditConflict:       "Der Film wurde durch eine andere Anfrage geändert",
+		codeInvalidReviewID:    "Ungültige Rezensions-ID",
+		codeReviewNotFound:     "Rezension nicht gefunden",
+		codePresetNotFound:     "Gespeicherter Filter nicht gefunden",
+		codeCollectionNotFound: "Sammlung nicht gefunden",
+	},
+}
+
+// preferredLanguage returns the best translated language for the Accept-Language header,
+// comparing primary subtags only ("fr-CA" is served "fr") and honoring q-values
+func preferredLanguage(c *gin.Context) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	choices := []choice{}
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := errorMessages[primary]; ok && q > 0 {
+			choices = append(choices, choice{primary, q})
+		}
+	}
+	if len(choices) == 0 {
+		return fallbackLanguage
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang
+}
+
+// conflictFields names the field a conflict code is about, reported as "field" so forms
+// can highlight it
+var conflictFields = map[string]string{
+	codeTitleTaken: "title",
+}
+
+// errorCode builds an English error body for code, for code paths without a request at
+// hand; localizeError translates it once the response is written
+func errorCode(code string, extra ...gin.H) gin.H {
+	message := errorMessages[fallbackLanguage][code]
+	body := gin.H{"error": message, "code": code, "message": message}
+	if field, ok := conflictFields[code]; ok {
+		body["field"] = field
+	}
+	for _, fields := range extra {
+		for key, value := range fields {
+			body[key] = value
+		}
+	}
+	return body
+}
+
+// localizeError rewrites the message of a body built by errorCode into the client's language.
+// Bodies without a code are returned unchanged.
+func localizeError(c *gin.Context, body gin.H) gin.H {
+	code, ok := body["code"].(string)
+	if !ok {
+		return body
+	}
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	if message, ok := errorMessages[preferredLanguage(c)][code]; ok {
+		body["error"] = message
+		body["message"] = message
+	}
+	return body
+}
+
+// apiError builds the error body for code in the client's language, merged with any extra
+// fields. "error" carries the same text as "message" for clients written before codes existed.
+func apiError(c *gin.Context, code string, extra ...gin.H) gin.H {
+	return localizeError(c, errorCode(code, extra...))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maximum accepted length of an Idempotency-Key header, matching the key column
+const maxIdempotencyKeyLength = 255
+
+// idempotencyKeyTTL is how long a used key replays its original response (IDEMPOTENCY_KEY_TTL, default 24h)
+func idempotencyKeyTTL() time.Duration {
+	return envDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+}
+
+// requestHash fingerprints a request body so a reused key can be told apart from a retry
+func requestHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// reserveIdempotencyKey claims key for this request inside tx, before anything is created.
+// A concurrent request with the same key blocks on the reservation until tx finishes, then
+// sees the stored response. When the key is already taken the response is written here:
+// the original 201 for a retry of the same body, a 422 for a different body, or a 409 while
+// the original request is still running. It reports whether the key was reserved.
+func reserveIdempotencyKey(c *gin.Context, tx *sql.Tx, key, hash string) (bool, error) {
+	ttl := idempotencyKeyTTL().Seconds()
+	// an expired key no longer replays, so this request may use it afresh
+	if _, err := tx.Exec("DELETE FROM idempotency_keys WHERE key = $1 AND created_at <= NOW() - $2 * INTERVAL '1 second'", key, ttl); err != nil {
+		return false, err
+	}
+	var reserved string
+	err := tx.QueryRow("INSERT INTO idempotency_keys (key, request_hash) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING RETURNING key", key, hash).Scan(&reserved)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	var storedHash sql.NullString
+	var movieID sql.NullInt64
+	var response []byte
+	if err := tx.QueryRow("SELECT request_hash, movie_id, response FROM idempotency_keys WHERE key = $1", key).Scan(&storedHash, &movieID, &response); err != nil {
+		return false, err
+	}
+	// keys saved before request hashes were recorded replay for any body
+	if storedHash.Valid && storedHash.String != hash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+		return false, nil
+	}
+	if response == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+		return false, nil
+	}
+
+	c.Header("Location", movieLocation(int(movieID.Int64)))
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(http.StatusCreated, "application/json; charset=utf-8", response)
+	return false, nil
+}
+
+// completeIdempotencyKey stores the created movie as the response key replays, in the
+// same transaction that reserved the key and created the movie
+func completeIdempotencyKey(tx *sql.Tx, key string, movie Movie) error {
+	response, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE idempotency_keys SET movie_id = $2, response = $3 WHERE key = $1", key, movie.ID, response)
+	return err
+}
+
+// releaseIdempotencyKey drops a reservation whose request created nothing to replay,
+// such as an upsert that updated an existing movie
+func releaseIdempotencyKey(tx *sql.Tx, key string) error {
+	_, err := tx.Exec("DELETE FROM idempotency_keys WHERE key = $1", key)
+	return err
+}
+
+// purgeExpiredIdempotencyKeys drops expired keys so the table doesn't grow without bound.
+// Failures are logged rather than returned since the request itself already succeeded.
+func purgeExpiredIdempotencyKeys() {
+	if _, err := db.Exec("DELETE FROM idempotency_keys WHERE created_at <= NOW() - $1 * INTERVAL '1 second'", idempotencyKeyTTL().Seconds()); err != nil {
+		log.Printf("Error purging expired idempotency keys: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// outcome of importing a single entry
+type importResult struct {
+	Index int    `json:"index"`
+	Title string `json:"title"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// importUpload returns the uploaded file from the "file" form field for multipart
+// requests, or the raw request body otherwise
+func importUpload(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing upload in form field \"file\": %w", err)
+		}
+		return header.Open()
+	}
+	return c.Request.Body, nil
+}
+
+// importMovie validates a single entry and inserts it within tx.
+// Each insert runs under a savepoint so one failing row doesn't abort the whole transaction.
+func importMovie(tx *sql.Tx, movie *Movie) error {
+	if err := binding.Validator.ValidateStruct(movie); err != nil {
+		return err
+	}
+	if err := prepareMovie(movie); err != nil {
+		return err
+	}
+	applyDefaultRating(movie)
+
+	if _, err := tx.Exec("SAVEPOINT import_entry"); err != nil {
+		return err
+	}
+	if err := insertImportedMovie(tx, movie); err != nil {
+		tx.Exec("ROLLBACK TO SAVEPOINT import_entry")
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT import_entry")
+	return err
+}
+
+// insertImportedMovie checks for a duplicate title and inserts the movie
+func insertImportedMovie(tx *sql.Tx, movie *Movie) error {
+	exists, err := titleExists(tx, movie.Title)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicate title: %w", err)
+	}
+	if exists {
+		return errors.New("Movie with this title already exists")
+	}
+	if err := insertMovie(tx, movie); err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("Movie with this title already exists")
+		}
+		return fmt.Errorf("failed to create movie: %w", err)
+	}
+	return nil
+}
+
+// importMoviesJSON imports a JSON array of movies, decoding it entry by entry.
+// Valid entries are inserted in one transaction; invalid ones are reported and skipped.
+// Malformed JSON aborts the import entirely.
+func importMoviesJSON(c *gin.Context) {
+	upload, err := importUpload(c)
+	if err != nil {
+		if !bodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer upload.Close()
+
+	decoder := json.NewDecoder(upload)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import must be a JSON array of movies"})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting import transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	results := []importResult{}
+	created := 0
+	for index := 0; decoder.More(); index++ {
+		var movie Movie
+		if err := decoder.Decode(&movie); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if bodyTooLarge(c, err) {
+				return
+			}
+			if !errors.As(err, &typeErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed JSON in import", "details": err.Error(), "index": index})
+				return
+			}
+			results = append(results, importResult{Index: index, Title: movie.Title, Error: err.Error()})
+			continue
+		}
+
+		if err := importMovie(tx, &movie); err != nil {
+			results = append(results, importResult{Index: index, Title: movie.Title, Error: err.Error()})
+			continue
+		}
+		results = append(results, importResult{Index: index, Title: movie.Title, ID: movie.ID})
+		created++
+	}
+	if _, err := decoder.Token(); err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed JSON in import", "details": err.Error()})
+		return
+	}
+
+	finishImport(c, tx, results, created)
+}
+
+// finishImport commits the import transaction and writes the summary. With dryRun=true
+// the transaction is rolled back instead, so the response only reports what would happen.
+func finishImport(c *gin.Context, tx *sql.Tx, results []importResult, created int, extra ...gin.H) {
+	if c.Query("dryRun") == "true" {
+		if err := tx.Rollback(); err != nil {
+			log.Printf("Error rolling back dry-run import: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back dry run", "details": err.Error()})
+			return
+		}
+		// the IDs were only assigned inside the rolled-back transaction
+		for i := range results {
+			results[i].ID = 0
+		}
+		c.JSON(http.StatusOK, importSummary(gin.H{"dryRun": true, "created": created, "failed": len(results) - created, "results": results}, extra))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit import", "details": err.Error()})
+		return
+	}
+	for _, result := range results {
+		if result.ID != 0 {
+			movieEvents.publish(movieCreated, result.ID)
+		}
+	}
+
+	c.JSON(http.StatusOK, importSummary(gin.H{"created": created, "failed": len(results) - created, "results": results}, extra))
+}
+
+// importSummary adds the importer-specific fields in extra to an import response
+func importSummary(body gin.H, extra []gin.H) gin.H {
+	for _, fields := range extra {
+		for key, value := range fields {
+			body[key] = value
+		}
+	}
+	return body
+}
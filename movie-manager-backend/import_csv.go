@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvColumns lists the header names accepted by the CSV importer, matching the JSON keys
+var csvColumns = []string{"title", "originalTitle", "genre", "year", "rating", "favorite", "watched", "language", "country", "runtimeMinutes"}
+
+// csvHeader maps each recognised column name to its position in the header row
+func csvHeader(header []string) (map[string]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		for _, column := range csvColumns {
+			if strings.EqualFold(name, column) {
+				positions[column] = i
+			}
+		}
+	}
+	if _, ok := positions["title"]; !ok {
+		return nil, fmt.Errorf("CSV header is missing the %q column", "title")
+	}
+	return positions, nil
+}
+
+// csvMovie builds a movie from one CSV record; empty cells leave the field at its zero value,
+// or unset for the year and rating
+func csvMovie(record []string, positions map[string]int) (Movie, error) {
+	var movie Movie
+	cell := func(column string) string {
+		if i, ok := positions[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(column string) (int, error) {
+		if cell(column) == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(cell(column))
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a whole number", column)
+		}
+		return n, nil
+	}
+	flag := func(column string) (bool, error) {
+		if cell(column) == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(cell(column))
+		if err != nil {
+			return false, fmt.Errorf("%s must be true or false", column)
+		}
+		return b, nil
+	}
+
+	var err error
+	movie.Title = cell("title")
+	movie.OriginalTitle = cell("originalTitle")
+	movie.Genre = cell("genre")
+	movie.Language = cell("language")
+	movie.Country = cell("country")
+	if cell("year") != "" {
+		year, err := number("year")
+		if err != nil {
+			return movie, err
+		}
+		movie.Year = &year
+	}
+	if cell("rating") != "" {
+		rating, err := number("rating")
+		if err != nil {
+			return movie, err
+		}
+		movie.Rating = &rating
+	}
+	if movie.RuntimeMinutes, err = number("runtimeMinutes"); err != nil {
+		return movie, err
+	}
+	if movie.Favorite, err = flag("favorite"); err != nil {
+		return movie, err
+	}
+	if movie.Watched, err = flag("watched"); err != nil {
+		return movie, err
+	}
+	return movie, nil
+}
+
+// csvRowParser builds a movie from one CSV record
+type csvRowParser func(record []string) (Movie, error)
+
+// importMoviesCSV imports movies from a CSV file with a header row naming the columns.
+// It follows the JSON importer: valid rows are inserted in one transaction and invalid
+// ones are reported and skipped. Pass dryRun=true to validate without writing anything.
+func importMoviesCSV(c *gin.Context) {
+	importCSV(c, func(header []string) (csvRowParser, gin.H, error) {
+		positions, err := csvHeader(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(record []string) (Movie, error) { return csvMovie(record, positions) }, nil, nil
+	})
+}
+
+// importCSV runs a CSV import: readHeader checks the header row and returns the parser for
+// the rows that follow, plus any extra fields for the response
+func importCSV(c *gin.Context, readHeader func(header []string) (csvRowParser, gin.H, error)) {
+	upload, err := importUpload(c)
+	if err != nil {
+		if !bodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer upload.Close()
+
+	reader := csv.NewReader(upload)
+	reader.FieldsPerRecord = -1 // short rows are treated as empty trailing cells
+	header, err := reader.Read()
+	if err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import must be a CSV file with a header row", "details": err.Error()})
+		return
+	}
+	parseRow, extra, err := readHeader(header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting import transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	results := []importResult{}
+	created := 0
+	for index := 0; ; index++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if bodyTooLarge(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed CSV in import", "details": err.Error(), "index": index})
+			return
+		}
+
+		movie, err := parseRow(record)
+		if err == nil {
+			err = importMovie(tx, &movie)
+		}
+		if err != nil {
+			results = append(results, importResult{Index: index, Title: movie.Title, Error: err.Error()})
+			continue
+		}
+		results = append(results, importResult{Index: index, Title: movie.Title, ID: movie.ID})
+		created++
+	}
+
+	finishImport(c, tx, results, created, extra)
+}
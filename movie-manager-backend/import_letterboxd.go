@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// letterboxdHeader maps the Letterboxd export columns the importer reads (Name and, when
+// present, Year and Rating) to their positions. Every other column, such as
+// Date and Letterboxd URI, is listed in ignored.
+func letterboxdHeader(header []string) (positions map[string]int, ignored []string, err error) {
+	positions = map[string]int{}
+	ignored = []string{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		switch name {
+		case "Name", "Year", "Rating":
+			positions[name] = i
+		default:
+			ignored = append(ignored, name)
+		}
+	}
+	for _, required := range []string{"Name"} {
+		if _, ok := positions[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing the %q column of a Letterboxd export", required)
+		}
+	}
+	return positions, ignored, nil
+}
+
+// letterboxdRating maps a Letterboxd rating of 0.5 to 5 stars in half-star steps onto
+// ratingScale(), rounding half stars up on the default scale of 5
+func letterboxdRating(raw string) (int, error) {
+	stars, err := strconv.ParseFloat(raw, 64)
+	if err != nil || stars < 0.5 || stars > 5 || stars*2 != math.Trunc(stars*2) {
+		return 0, fmt.Errorf("Rating %q is not a Letterboxd rating of 0.5 to 5 stars", raw)
+	}
+	return rescaleRating(stars, 5), nil
+}
+
+// letterboxdMovie builds a movie from one row of a Letterboxd export
+func letterboxdMovie(record []string, positions map[string]int, watched bool) (Movie, error) {
+	cell := func(column string) string {
+		if i, ok := positions[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	movie := Movie{Title: cell("Name"), Watched: watched}
+	if cell("Year") != "" {
+		year, err := strconv.Atoi(cell("Year"))
+		if err != nil {
+			return movie, fmt.Errorf("Year must be a whole number")
+		}
+		movie.Year = &year
+	}
+	if cell("Rating") != "" {
+		rating, err := letterboxdRating(cell("Rating"))
+		if err != nil {
+			return movie, err
+		}
+		movie.Rating = &rating
+	}
+	return movie, nil
+}
+
+// importLetterboxd imports a Letterboxd CSV export (ratings, diary, watched or watchlist).
+// Rows are handled like the CSV importer's, so duplicate titles are reported and skipped.
+// Imported movies are marked watched unless watched=false is passed, e.g. for a watchlist.
+func importLetterboxd(c *gin.Context) {
+	watched := true
+	if raw, ok := c.GetQuery("watched"); ok {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "watched must be true or false"})
+			return
+		}
+		watched = parsed
+	}
+
+	importCSV(c, func(header []string) (csvRowParser, gin.H, error) {
+		positions, ignored, err := letterboxdHeader(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		parse := func(record []string) (Movie, error) { return letterboxdMovie(record, positions, watched) }
+		return parse, gin.H{"ignoredColumns": ignored}, nil
+	})
+}
@@ -1,314 +1,1826 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // movie model
 type Movie struct {
-	ID     int    `json:"id"`
-	Title  string `json:"title" binding:"required"`
-	Genre  string `json:"genre"`
-	Year   int    `json:"year" binding:"required"`
-	Rating int    `json:"rating" binding:"gte=0,lte=5"`
+	XMLName        xml.Name  `json:"-" xml:"movie"`
+	ID             int       `json:"id" xml:"id"`
+	Title          string    `json:"title" xml:"title" binding:"required"`
+	OriginalTitle  string    `json:"originalTitle" xml:"originalTitle"` // title in the original language, "" if the same or unknown
+	Genre          string    `json:"genre" xml:"genre"`
+	Year           *int      `json:"year" xml:"year"`                               // nil for unreleased or unknown
+	Rating         *int      `json:"rating" xml:"rating" binding:"omitempty,gte=0"` // 0 to ratingScale(); nil means not yet rated
+	Favorite       bool      `json:"favorite" xml:"favorite"`
+	Watched        bool      `json:"watched" xml:"watched"`
+	Archived       bool      `json:"archived" xml:"archived"` // hidden from listings and stats unless asked for
+	Language       string    `json:"language" xml:"language"`
+	Country        string    `json:"country" xml:"country"`
+	RuntimeMinutes int       `json:"runtimeMinutes" xml:"runtimeMinutes" binding:"gte=0"`
+	PosterURL      string    `json:"posterUrl" xml:"posterUrl"`
+	Description    string    `json:"description" xml:"description"`
+	Version        int       `json:"version" xml:"version"`
+	CreatedAt      time.Time `json:"createdAt" xml:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt" xml:"updatedAt"`
+
+	// set by the database when favorite flips to true, nil otherwise
+	FavoritedAt *time.Time `json:"favoritedAt" xml:"favoritedAt"`
+
+	// maintained by the database from per-user ratings and reviews; AverageRating
+	// falls back to Rating while no user has rated the movie, and is nil if neither exists
+	AverageRating *float64 `json:"averageRating" xml:"averageRating"`
+	RatingCount   int      `json:"ratingCount" xml:"ratingCount"`
+	ReviewCount   int      `json:"reviewCount" xml:"reviewCount"`
+
+	// the franchise the movie belongs to, managed through the /collections endpoints; nil if none
+	CollectionID *int    `json:"collectionId" xml:"collectionId"`
+	Collection   *string `json:"collection" xml:"collection"`
+}
+
+// response body for POST /movies?upsert=true, flagging whether an existing movie was overwritten
+type upsertResponse struct {
+	Movie
+	Updated bool `json:"updated"`
+}
+
+// struct for handling partial updates.
+// Version is the version the client last read; the update is rejected if the row has moved on.
+// It may be omitted when the request carries an If-Unmodified-Since header instead.
+//
+// Genre has three states: omitted leaves it unchanged, "" stores an empty string,
+// and clearGenre: true sets the column to NULL (genre must then be omitted).
+// Likewise clearRating: true marks the movie as not yet rated, and clearYear: true
+// records the release year as unknown.
+type UpdateMovieInput struct {
+	Title          *string `json:"title"`
+	OriginalTitle  *string `json:"originalTitle"`
+	Genre          *string `json:"genre"`
+	Year           *int    `json:"year"`
+	Rating         *int    `json:"rating"`
+	Favorite       *bool   `json:"favorite"`
+	Watched        *bool   `json:"watched"`
+	Archived       *bool   `json:"archived"`
+	Language       *string `json:"language"`
+	Country        *string `json:"country"`
+	RuntimeMinutes *int    `json:"runtimeMinutes"`
+	Version        *int    `json:"version"`
+
+	ClearGenre  bool `json:"clearGenre"`
+	ClearRating bool `json:"clearRating"`
+	ClearYear   bool `json:"clearYear"`
+}
+
+// columns selected for a full movie row, in the order scanMovie expects
+const movieColumns = "id, title, original_title, genre, year, rating, favorite, watched, archived, language, country, runtime_minutes, poster_url, description, version, created_at, updated_at, favorited_at, rating_average, rating_count, review_count, " +
+	"collection_id, (SELECT name FROM collections WHERE collections.id = movies.collection_id)"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMovie reads a row selected with movieColumns into movie.
+// NULL text columns are reported as empty strings and a NULL runtime as 0.
+func scanMovie(row rowScanner, movie *Movie) error {
+	var originalTitle, genre, language, country, posterURL, description sql.NullString
+	var runtime sql.NullInt64
+	var average sql.NullFloat64
+	err := row.Scan(&movie.ID, &movie.Title, &originalTitle, &genre, &movie.Year, &movie.Rating, &movie.Favorite, &movie.Watched, &movie.Archived,
+		&language, &country, &runtime, &posterURL, &description, &movie.Version, &movie.CreatedAt, &movie.UpdatedAt, &movie.FavoritedAt,
+		&average, &movie.RatingCount, &movie.ReviewCount, &movie.CollectionID, &movie.Collection)
+	if average.Valid {
+		movie.AverageRating = &average.Float64
+	} else if movie.Rating != nil {
+		fallback := float64(*movie.Rating)
+		movie.AverageRating = &fallback
+	}
+	movie.OriginalTitle = originalTitle.String
+	movie.Genre = genre.String
+	movie.Language = language.String
+	movie.Country = country.String
+	movie.PosterURL = posterURL.String
+	movie.Description = description.String
+	movie.RuntimeMinutes = int(runtime.Int64)
+	return err
+}
+
+// columns written from a Movie on insert and full replacement, in the order movieValues returns them
+var movieWriteColumns = []string{"title", "original_title", "genre", "year", "rating", "favorite", "watched", "archived", "language", "country", "runtime_minutes", "poster_url", "description"}
+
+// movieValues returns the movie's values for movieWriteColumns
+func movieValues(movie *Movie) []interface{} {
+	return []interface{}{movie.Title, movie.OriginalTitle, movie.Genre, movie.Year, movie.Rating, movie.Favorite, movie.Watched, movie.Archived, movie.Language, movie.Country, movie.RuntimeMinutes, movie.PosterURL, movie.Description}
+}
+
+// applyDefaultRating gives a new movie created without a rating the DEFAULT_RATING (0 to ratingScale()).
+// When DEFAULT_RATING is unset the movie is stored as not yet rated.
+func applyDefaultRating(movie *Movie) {
+	raw := os.Getenv("DEFAULT_RATING")
+	if movie.Rating != nil || raw == "" {
+		return
+	}
+	rating, err := strconv.Atoi(raw)
+	if err != nil || validateRating(rating) != nil {
+		log.Printf("Warning: invalid DEFAULT_RATING %q, leaving the movie unrated", raw)
+		return
+	}
+	movie.Rating = &rating
+}
+
+// intPtr returns a pointer to n, for optional fields such as Movie.Rating
+func intPtr(n int) *int {
+	return &n
+}
+
+// queryMovies runs a query selecting movieColumns and scans every row
+func queryMovies(query string, args ...interface{}) ([]Movie, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var movie Movie
+		if err := scanMovie(rows, &movie); err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+	return movies, rows.Err()
+}
+
+var db *sql.DB
+
+// unaccentEnabled is set when the unaccent extension is available, making search accent-insensitive
+var unaccentEnabled bool
+
+// initializes the PostgreSQL
+func initDB() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Warning: Could not load .env file.%v", err)
+	}
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		log.Fatalf("Fatal: DATABASE_URL environment variable is not set.")
+	} else {
+		log.Println("DATABASE_URL successfully loaded from environment.")
+	}
+
+	schema, err := dbSchema()
+	if err != nil {
+		log.Fatalf("Fatal: %v", err)
+	}
+	if schema != "" {
+		if connStr, err = withSearchPath(connStr, schema); err != nil {
+			log.Fatalf("Fatal: DATABASE_URL could not be parsed to apply DB_SCHEMA: %v", err)
+		}
+		log.Printf("Using database schema %s.", schema)
+	}
+
+	var openErr error
+	db, openErr = sql.Open("postgres", connStr)
+	if openErr != nil {
+		log.Fatalf("Error opening database connection with string '%s': %v", connStr, openErr)
+	}
+
+	// retry with exponential backoff so the app can start alongside the database
+	attempts := envInt("DB_CONNECT_ATTEMPTS", 5)
+	backoff := envDuration("DB_CONNECT_BACKOFF", time.Second)
+	var pingErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if pingErr = db.Ping(); pingErr == nil {
+			break
+		}
+		if attempt == attempts {
+			break
+		}
+		log.Printf("Database not ready (attempt %d/%d): %v; retrying in %s", attempt, attempts, pingErr, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if pingErr != nil {
+		log.Fatalf("Error connecting to the database with string '%s' after %d attempts: %v", connStr, attempts, pingErr)
+	}
+
+	log.Println("Successfully connected to PostgreSQL database!")
+
+	if schema != "" {
+		if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(schema)); err != nil {
+			log.Fatalf("Error creating database schema %s: %v", schema, err)
+		}
+	}
+
+	if err := runMigrations(db); err != nil {
+		log.Fatalf("Error running database migrations: %v", err)
+	}
+	log.Println("Database schema is up to date.")
+
+	if err := applyRatingScale(db); err != nil {
+		log.Fatalf("Error applying rating scale: %v", err)
+	}
+
+	initUnaccent()
+}
+
+// schema names accepted in DB_SCHEMA: unquoted lowercase Postgres identifiers
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// dbSchema returns the DB_SCHEMA to keep this instance's tables in, or "" for the
+// connection's default. Tenants sharing a database each get their own schema.
+// DB_TABLE is rejected unless it names the default table: the queries, migrations and
+// triggers all refer to movies, and a schema already keeps tenants apart.
+func dbSchema() (string, error) {
+	if table := os.Getenv("DB_TABLE"); table != "" && table != "movies" {
+		return "", fmt.Errorf("DB_TABLE=%q is not supported; set DB_SCHEMA to separate tenants instead", table)
+	}
+	schema := os.Getenv("DB_SCHEMA")
+	if schema != "" && !schemaNamePattern.MatchString(schema) {
+		return "", fmt.Errorf("DB_SCHEMA=%q must be a lowercase identifier of letters, digits and underscores", schema)
+	}
+	return schema, nil
+}
+
+// withSearchPath adds a search_path of schema, then public, to a connection string in
+// either URL or key=value form. Tables are created in and read from schema, while
+// extensions such as unaccent installed in public stay visible.
+func withSearchPath(connStr, schema string) (string, error) {
+	searchPath := schema + ",public"
+	if !strings.HasPrefix(connStr, "postgres://") && !strings.HasPrefix(connStr, "postgresql://") {
+		return connStr + " search_path=" + searchPath, nil
+	}
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("search_path", searchPath)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// initUnaccent enables the unaccent extension unless UNACCENT_SEARCH=false.
+// Managed databases may not allow creating extensions, in which case search
+// falls back to plain ILIKE.
+func initUnaccent() {
+	if enabled, err := strconv.ParseBool(os.Getenv("UNACCENT_SEARCH")); err == nil && !enabled {
+		log.Println("Accent-insensitive search disabled by UNACCENT_SEARCH.")
+		return
+	}
+	if _, err := db.Exec("CREATE EXTENSION IF NOT EXISTS unaccent"); err != nil {
+		log.Printf("Warning: unaccent extension unavailable, search will be accent-sensitive: %v", err)
+		return
+	}
+	unaccentEnabled = true
+	log.Println("Accent-insensitive search enabled.")
+}
+
+// ilikeClause builds "column ILIKE $n", folding accents on both sides when unaccent is enabled.
+// The pattern must be built with escapeLike so user input can't inject wildcards.
+func ilikeClause(column string, placeholder int) string {
+	if unaccentEnabled {
+		return fmt.Sprintf(`unaccent(%s) ILIKE unaccent($%d) ESCAPE '\'`, column, placeholder)
+	}
+	return fmt.Sprintf(`%s ILIKE $%d ESCAPE '\'`, column, placeholder)
+}
+
+// maximum title length, matching the VARCHAR(255) column
+const maxTitleLength = 255
+
+// normalizeTitle trims surrounding whitespace so " Inception " and "Inception"
+// are treated as the same title, and rejects blank or overly long titles
+func normalizeTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("Title must not be blank")
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", fmt.Errorf("Title must be at most %d characters", maxTitleLength)
+	}
+	return title, nil
+}
+
+// normalizeOriginalTitle trims the original-language title, which unlike the display title
+// may be blank and need not be unique
+func normalizeOriginalTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", fmt.Errorf("Original title must be at most %d characters", maxTitleLength)
+	}
+	return title, nil
+}
+
+// how long the database's year is reused before currentYear reads it again
+const dbYearTTL = time.Minute
+
+// dbYearCache holds the last year read from the database clock
+var dbYearCache struct {
+	sync.Mutex
+	year    int
+	checked time.Time
+}
+
+// currentYear returns the current calendar year in UTC. Both the app server and the
+// database clock are consulted and the earlier year wins, so a year that is still in the
+// future for either of them is never accepted around New Year. The database's year is
+// cached for dbYearTTL, so validating every row of an import or batch costs no round trips.
+func currentYear() int {
+	year := time.Now().UTC().Year()
+
+	dbYearCache.Lock()
+	defer dbYearCache.Unlock()
+	if time.Since(dbYearCache.checked) >= dbYearTTL {
+		var dbYear int
+		if err := db.QueryRow("SELECT EXTRACT(YEAR FROM NOW() AT TIME ZONE 'UTC')::int").Scan(&dbYear); err != nil {
+			log.Printf("Warning: could not read current year from database, using server clock: %v", err)
+			return year
+		}
+		dbYearCache.year, dbYearCache.checked = dbYear, time.Now()
+	}
+	return min(year, dbYearCache.year)
+}
+
+// earliest release year accepted
+const minYear = 1900
+
+// validateYear checks that a release year is between minYear and the current UTC year
+func validateYear(year int) error {
+	currentYear := currentYear()
+	if year < minYear || year > currentYear {
+		return fmt.Errorf("Year must be between %d and %d", minYear, currentYear)
+	}
+	return nil
+}
+
+// fieldErrors maps each invalid JSON field of a movie to a readable message
+type fieldErrors map[string]string
+
+// Error lists the messages by field name, for callers that report a single string such as imports
+func (e fieldErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = e[name]
+	}
+	return strings.Join(messages, "; ")
+}
+
+// prepareMovie normalizes a movie's text fields and checks the business rules
+// that binding tags can't express. Every invalid field is reported, as a fieldErrors.
+func prepareMovie(movie *Movie) error {
+	fields := fieldErrors{}
+	normalize := func(name string, value *string, normalizer func(string) (string, error)) {
+		normalized, err := normalizer(*value)
+		if err != nil {
+			fields[name] = err.Error()
+			return
+		}
+		*value = normalized
+	}
+	normalize("title", &movie.Title, normalizeTitle)
+	normalize("originalTitle", &movie.OriginalTitle, normalizeOriginalTitle)
+	normalize("genre", &movie.Genre, normalizeGenre)
+	normalize("language", &movie.Language, normalizeLanguage)
+	normalize("country", &movie.Country, normalizeCountry)
+	if movie.Rating != nil {
+		if err := validateRating(*movie.Rating); err != nil {
+			fields["rating"] = err.Error()
+		}
+	}
+	if movie.Year != nil {
+		if err := validateYear(*movie.Year); err != nil {
+			fields["year"] = err.Error()
+		}
+	}
+	if len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
+
+// maximum language length, matching the VARCHAR(50) column
+const maxLanguageLength = 50
+
+// normalizeLanguage trims the language and enforces maxLanguageLength
+func normalizeLanguage(language string) (string, error) {
+	language = strings.TrimSpace(language)
+	if utf8.RuneCountInString(language) > maxLanguageLength {
+		return "", fmt.Errorf("Language must be at most %d characters", maxLanguageLength)
+	}
+	return language, nil
+}
+
+// maximum country length, matching the VARCHAR(100) column
+const maxCountryLength = 100
+
+// normalizeCountry trims the country of origin and enforces maxCountryLength
+func normalizeCountry(country string) (string, error) {
+	country = strings.TrimSpace(country)
+	if utf8.RuneCountInString(country) > maxCountryLength {
+		return "", fmt.Errorf("Country must be at most %d characters", maxCountryLength)
+	}
+	return country, nil
+}
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx so helpers can run inside or outside a transaction
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// upsertMovie inserts the movie, or overwrites the fields of the existing movie with the
+// same title, and reports whether a new row was created. The unique constraint on title is
+// case-sensitive while duplicates are judged ignoring case, so the conflict is raised on the
+// stored spelling and the title is then updated to the new one.
+func upsertMovie(q dbtx, movie *Movie) (bool, error) {
+	conflictTitle := movie.Title
+	err := q.QueryRow("SELECT title FROM movies WHERE LOWER(title) = LOWER($1) LIMIT 1", movie.Title).Scan(&conflictTitle)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	values := movieValues(movie)
+	values[0] = conflictTitle
+	placeholders := make([]string, len(movieWriteColumns))
+	updates := make([]string, len(movieWriteColumns))
+	for i, column := range movieWriteColumns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+	}
+	updates[0] = fmt.Sprintf("title = $%d", len(values)+1)
+	values = append(values, movie.Title)
+
+	// xmax is only zero for a row this statement inserted
+	query := fmt.Sprintf(`INSERT INTO movies (%s) VALUES (%s)
+	ON CONFLICT (title) DO UPDATE SET %s, version = movies.version + 1
+	RETURNING id, version, created_at, updated_at, favorited_at, (xmax = 0)`,
+		strings.Join(movieWriteColumns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+
+	var created bool
+	err = q.QueryRow(query, values...).Scan(&movie.ID, &movie.Version, &movie.CreatedAt, &movie.UpdatedAt, &movie.FavoritedAt, &created)
+	return created, err
+}
+
+// titleExists reports whether a movie with the given title (case-insensitive) is already stored
+func titleExists(q dbtx, title string) (bool, error) {
+	return titleTakenByOther(q, title, 0)
+}
+
+// titleOwner returns the ID of the movie stored under title (case-insensitive),
+// or sql.ErrNoRows if there is none
+func titleOwner(q dbtx, title string) (int, error) {
+	var id int
+	err := q.QueryRow("SELECT id FROM movies WHERE LOWER(title) = LOWER($1) ORDER BY id LIMIT 1", title).Scan(&id)
+	return id, err
+}
+
+// titleTakenByOther reports whether a movie other than id already uses the given title
+func titleTakenByOther(q dbtx, title string, id int) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM movies WHERE LOWER(title) = LOWER($1) AND id != $2)", title, id).Scan(&exists)
+	return exists, err
+}
+
+// Postgres error code for a unique constraint violation
+const uniqueViolationCode = "23505"
+
+// isUniqueViolation reports whether err is a unique constraint violation. The title checks
+// above only make duplicates unlikely: two concurrent writers can both pass them, and the
+// loser is then stopped by the constraint on movies.title.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// insertMovie stores a new movie and fills in its generated ID and timestamp
+func insertMovie(q dbtx, movie *Movie) error {
+	placeholders := make([]string, len(movieWriteColumns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO movies (%s) VALUES (%s) RETURNING id, version, created_at, updated_at, favorited_at",
+		strings.Join(movieWriteColumns, ", "), strings.Join(placeholders, ", "))
+	return q.QueryRow(query, movieValues(movie)...).Scan(&movie.ID, &movie.Version, &movie.CreatedAt, &movie.UpdatedAt, &movie.FavoritedAt)
+}
+
+// create
+// A request carrying an Idempotency-Key header that was already used within the
+// expiry window gets the original 201 response back instead of creating another movie,
+// provided the body is the same; reusing a key for a different body is a 422.
+func createMovie(c *gin.Context) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	var bodyHash string
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength)})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if !bodyTooLarge(c, err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body", "details": err.Error()})
+			}
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash = requestHash(body)
+	}
+
+	var movie Movie
+	if !bindJSON(c, &movie) {
+		return
+	}
+
+	// Normalizing and validating fields
+	if err := prepareMovie(&movie); err != nil {
+		respondInvalidMovie(c, err)
+		return
+	}
+	applyDefaultRating(&movie)
+
+	// with skipDuplicates=true a title conflict leaves the existing movie alone and is
+	// reported with its ID instead of failing
+	skipDuplicates := c.Query("skipDuplicates") == "true"
+	if skipDuplicates && c.Query("upsert") == "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either upsert or skipDuplicates, not both"})
+		return
+	}
+
+	// the idempotency key is reserved in the same transaction that creates the movie,
+	// so concurrent retries can't both create one
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	if idempotencyKey != "" {
+		reserved, err := reserveIdempotencyKey(c, tx, idempotencyKey, bodyHash)
+		if err != nil {
+			log.Printf("Error reserving idempotency key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key", "details": err.Error()})
+			return
+		}
+		if !reserved {
+			return
+		}
+	}
+
+	// with upsert=true a title conflict updates the existing movie instead of failing
+	if c.Query("upsert") == "true" {
+		created, err := upsertMovie(tx, &movie)
+		if err == nil && idempotencyKey != "" {
+			if created {
+				err = completeIdempotencyKey(tx, idempotencyKey, movie)
+			} else {
+				err = releaseIdempotencyKey(tx, idempotencyKey)
+			}
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("Error upserting movie: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save movie", "details": err.Error()})
+			return
+		}
+		status, action := http.StatusOK, movieUpdated
+		if created {
+			status, action = http.StatusCreated, movieCreated
+		}
+		if idempotencyKey != "" {
+			purgeExpiredIdempotencyKeys()
+		}
+		movieEvents.publish(action, movie.ID)
+		c.Header("Location", movieLocation(movie.ID))
+		c.JSON(status, upsertResponse{Movie: movie, Updated: !created})
+		return
+	}
+
+	// Checking for duplicate title
+	exists, err := titleExists(tx, movie.Title)
+	if err != nil {
+		log.Printf("Error checking for duplicate title: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+		return
+	}
+	if exists {
+		respondDuplicate(c, movie.Title, skipDuplicates)
+		return
+	}
+
+	if err := insertMovie(tx, &movie); err != nil {
+		if isUniqueViolation(err) {
+			respondDuplicate(c, movie.Title, skipDuplicates)
+			return
+		}
+		log.Printf("Error inserting movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+		return
+	}
+	if idempotencyKey != "" {
+		if err := completeIdempotencyKey(tx, idempotencyKey, movie); err != nil {
+			log.Printf("Error saving idempotency key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+		return
+	}
+
+	if idempotencyKey != "" {
+		purgeExpiredIdempotencyKeys()
+	}
+	movieEvents.publish(movieCreated, movie.ID)
+
+	c.Header("Location", movieLocation(movie.ID))
+	c.JSON(http.StatusCreated, movie)
+}
+
+// respondDuplicate answers a create whose title is already taken: a 409, or with
+// skipDuplicates a 200 naming the existing movie
+func respondDuplicate(c *gin.Context, title string, skipDuplicates bool) {
+	if !skipDuplicates {
+		c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+		return
+	}
+	existingID, err := titleOwner(db, title)
+	if err != nil {
+		log.Printf("Error looking up duplicate title: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"skipped": true, "existingId": existingID})
+}
+
+// movieETag derives a strong ETag from the movie's fields, including updated_at
+func movieETag(movie Movie) string {
+	payload, _ := json.Marshal(movie)
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf("\"%x\"", sum[:16])
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchMovie loads a single movie by ID, returning sql.ErrNoRows if it doesn't exist
+func fetchMovie(id int) (Movie, error) {
+	var movie Movie
+	err := scanMovie(db.QueryRow(fmt.Sprintf("SELECT %s FROM movies WHERE id = $1", movieColumns), id), &movie)
+	return movie, err
+}
+
+// getMovieByTitle returns the movie whose title matches exactly, ignoring case.
+// Unlike the search parameter this is not a fuzzy match; it is meant for sync scripts
+// checking whether a title exists before inserting.
+func getMovieByTitle(c *gin.Context) {
+	title := strings.TrimSpace(c.Query("title"))
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title query parameter is required"})
+		return
+	}
+
+	var movie Movie
+	err := scanMovie(db.QueryRow(fmt.Sprintf("SELECT %s FROM movies WHERE LOWER(title) = LOWER($1)", movieColumns), title), &movie)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie by title: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	respond(c, http.StatusOK, movie)
 }
 
-// struct for handling partial updates
-type UpdateMovieInput struct {
-	Title  *string `json:"title"`
-	Genre  *string `json:"genre"`
-	Year   *int    `json:"year"`
-	Rating *int    `json:"rating"`
-}
+// getMovie returns a single movie by ID, honoring If-None-Match
+func getMovie(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	etag := movieETag(movie)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", movie.UpdatedAt.UTC().Format(http.TimeFormat))
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	respond(c, http.StatusOK, movie)
+}
+
+// updateMovie handles partially updating an existing movie; only fields present in the body change
+func updateMovie(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	var input UpdateMovieInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	// an unparseable date is ignored, as RFC 9110 requires
+	var unmodifiedSince *time.Time
+	if t, err := http.ParseTime(c.GetHeader("If-Unmodified-Since")); err == nil {
+		unmodifiedSince = &t
+	}
+	if input.Version == nil && unmodifiedSince == nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"errors": map[string]string{"version": "required"}}))
+		return
+	}
+
+	newVersion, updateErr := applyMovieUpdate(db, id, &input, unmodifiedSince)
+	if updateErr != nil {
+		c.JSON(updateErr.status, localizeError(c, updateErr.body))
+		return
+	}
+	movieEvents.publish(movieUpdated, id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Movie updated successfully", "id": id, "version": newVersion})
+}
+
+// updateError is a failed partial update together with the response it maps to
+type updateError struct {
+	status int
+	body   gin.H
+}
+
+func badUpdate(msg string) *updateError {
+	return &updateError{http.StatusBadRequest, gin.H{"error": msg}}
+}
+
+// badField is a 400 for one invalid field, in the validation_failed shape bindJSON uses
+func badField(field string, err error) *updateError {
+	return &updateError{http.StatusBadRequest, errorCode(codeValidationFailed, gin.H{"errors": map[string]string{field: err.Error()}})}
+}
+
+// applyMovieUpdate validates a partial update and applies it to movie id through q,
+// guarded by the expected version in the input and, when set, by unmodifiedSince.
+// It returns the movie's new version.
+func applyMovieUpdate(q dbtx, id int, input *UpdateMovieInput, unmodifiedSince *time.Time) (int, *updateError) {
+	setClauses := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if input.Title != nil {
+		title, err := normalizeTitle(*input.Title)
+		if err != nil {
+			return 0, badField("title", err)
+		}
+		input.Title = &title
+
+		taken, err := titleTakenByOther(q, *input.Title, id)
+		if err != nil {
+			log.Printf("Error checking for duplicate title on update: %v", err)
+			return 0, &updateError{http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()}}
+		}
+		if taken {
+			return 0, &updateError{http.StatusConflict, errorCode(codeTitleTaken)}
+		}
+
+		setClauses = append(setClauses, fmt.Sprintf("title = $%d", argCount))
+		args = append(args, *input.Title)
+		argCount++
+	}
+	if input.OriginalTitle != nil {
+		originalTitle, err := normalizeOriginalTitle(*input.OriginalTitle)
+		if err != nil {
+			return 0, badField("originalTitle", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("original_title = $%d", argCount))
+		args = append(args, originalTitle)
+		argCount++
+	}
+	if input.ClearGenre {
+		if input.Genre != nil {
+			return 0, badUpdate("Provide either genre or clearGenre, not both")
+		}
+		setClauses = append(setClauses, "genre = NULL")
+	}
+	if input.Genre != nil {
+		genre, err := normalizeGenre(*input.Genre)
+		if err != nil {
+			return 0, badField("genre", err)
+		}
+		input.Genre = &genre
+		setClauses = append(setClauses, fmt.Sprintf("genre = $%d", argCount))
+		args = append(args, *input.Genre)
+		argCount++
+	}
+	if input.ClearYear {
+		if input.Year != nil {
+			return 0, badUpdate("Provide either year or clearYear, not both")
+		}
+		setClauses = append(setClauses, "year = NULL")
+	}
+	if input.Year != nil {
+		if err := validateYear(*input.Year); err != nil {
+			return 0, badField("year", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("year = $%d", argCount))
+		args = append(args, *input.Year)
+		argCount++
+	}
+	if input.ClearRating {
+		if input.Rating != nil {
+			return 0, badUpdate("Provide either rating or clearRating, not both")
+		}
+		setClauses = append(setClauses, "rating = NULL")
+	}
+	if input.Rating != nil {
+		if err := validateRating(*input.Rating); err != nil {
+			return 0, badField("rating", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("rating = $%d", argCount))
+		args = append(args, *input.Rating)
+		argCount++
+	}
+	if input.Language != nil {
+		language, err := normalizeLanguage(*input.Language)
+		if err != nil {
+			return 0, badField("language", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("language = $%d", argCount))
+		args = append(args, language)
+		argCount++
+	}
+	if input.Country != nil {
+		country, err := normalizeCountry(*input.Country)
+		if err != nil {
+			return 0, badField("country", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("country = $%d", argCount))
+		args = append(args, country)
+		argCount++
+	}
+	if input.RuntimeMinutes != nil {
+		if *input.RuntimeMinutes < 0 {
+			return 0, badUpdate("Runtime must not be negative")
+		}
+		setClauses = append(setClauses, fmt.Sprintf("runtime_minutes = $%d", argCount))
+		args = append(args, *input.RuntimeMinutes)
+		argCount++
+	}
+	// checked against nil rather than its value so an explicit false is still applied
+	if input.Favorite != nil {
+		setClauses = append(setClauses, fmt.Sprintf("favorite = $%d", argCount))
+		args = append(args, *input.Favorite)
+		argCount++
+	}
+	if input.Watched != nil {
+		setClauses = append(setClauses, fmt.Sprintf("watched = $%d", argCount))
+		args = append(args, *input.Watched)
+		argCount++
+	}
+	if input.Archived != nil {
+		setClauses = append(setClauses, fmt.Sprintf("archived = $%d", argCount))
+		args = append(args, *input.Archived)
+		argCount++
+	}
+
+	if len(setClauses) == 0 {
+		return 0, badUpdate("No fields to update provided")
+	}
+
+	setClauses = append(setClauses, "version = version + 1")
+	// the ID and preconditions go last for the WHERE clause
+	conditions := []string{fmt.Sprintf("id = $%d", argCount)}
+	args = append(args, id)
+	argCount++
+	if input.Version != nil {
+		conditions = append(conditions, fmt.Sprintf("version = $%d", argCount))
+		args = append(args, *input.Version)
+		argCount++
+	}
+	if unmodifiedSince != nil {
+		// HTTP dates have whole-second precision
+		conditions = append(conditions, fmt.Sprintf("date_trunc('second', updated_at) <= $%d", argCount))
+		args = append(args, *unmodifiedSince)
+	}
+	query := fmt.Sprintf("UPDATE movies SET %s WHERE %s RETURNING version",
+		strings.Join(setClauses, ", "), strings.Join(conditions, " AND "))
+
+	var newVersion int
+	err := q.QueryRow(query, args...).Scan(&newVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// either the movie is gone or someone else updated it first
+			var currentVersion int
+			var lastModified time.Time
+			lookupErr := q.QueryRow("SELECT version, updated_at FROM movies WHERE id = $1", id).Scan(&currentVersion, &lastModified)
+			if lookupErr == sql.ErrNoRows {
+				return 0, &updateError{http.StatusNotFound, errorCode(codeMovieNotFound)}
+			}
+			if lookupErr != nil {
+				log.Printf("Error checking movie version: %v", lookupErr)
+				return 0, &updateError{http.StatusInternalServerError, gin.H{"error": "Failed to update movie", "details": lookupErr.Error()}}
+			}
+			if input.Version == nil || *input.Version == currentVersion {
+				return 0, &updateError{http.StatusPreconditionFailed, gin.H{
+					"error":        "Movie was modified since If-Unmodified-Since",
+					"lastModified": lastModified.UTC().Format(http.TimeFormat),
+				}}
+			}
+			return 0, &updateError{http.StatusConflict, errorCode(codeEditConflict, gin.H{"currentVersion": currentVersion})}
+		}
+		if isUniqueViolation(err) {
+			return 0, &updateError{http.StatusConflict, errorCode(codeTitleTaken)}
+		}
+		log.Printf("Error updating movie: %v", err)
+		return 0, &updateError{http.StatusInternalServerError, gin.H{"error": "Failed to update movie", "details": err.Error()}}
+	}
+	return newVersion, nil
+}
+
+// replaceMovie overwrites every field of an existing movie (full-replacement PUT).
+// Unlike updateMovie, which patches only the fields present in the body, any field
+// omitted here is reset to its zero value. The version is optional: when supplied it
+// is checked like in updateMovie, when omitted the replacement is unconditional.
+func replaceMovie(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	var movie Movie
+	if !bindJSON(c, &movie) {
+		return
+	}
+
+	if err := prepareMovie(&movie); err != nil {
+		respondInvalidMovie(c, err)
+		return
+	}
+
+	taken, err := titleTakenByOther(db, movie.Title, id)
+	if err != nil {
+		log.Printf("Error checking for duplicate title on replace: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+		return
+	}
+
+	setClauses := make([]string, len(movieWriteColumns))
+	for i, column := range movieWriteColumns {
+		setClauses[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	args := append(movieValues(&movie), id, movie.Version)
+	query := fmt.Sprintf("UPDATE movies SET %s, version = version + 1 WHERE id = $%d AND ($%d = 0 OR version = $%d) RETURNING id, version, created_at, updated_at, favorited_at",
+		strings.Join(setClauses, ", "), len(args)-1, len(args), len(args))
+	err = db.QueryRow(query, args...).Scan(&movie.ID, &movie.Version, &movie.CreatedAt, &movie.UpdatedAt, &movie.FavoritedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			var currentVersion int
+			lookupErr := db.QueryRow("SELECT version FROM movies WHERE id = $1", id).Scan(&currentVersion)
+			if lookupErr == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+				return
+			}
+			if lookupErr != nil {
+				log.Printf("Error checking movie version: %v", lookupErr)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace movie", "details": lookupErr.Error()})
+				return
+			}
+			c.JSON(http.StatusConflict, apiError(c, codeEditConflict, gin.H{"currentVersion": currentVersion}))
+			return
+		}
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+			return
+		}
+		log.Printf("Error replacing movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace movie", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieUpdated, id)
+
+	c.JSON(http.StatusOK, movie)
+}
+
+// movieFilter accumulates WHERE clauses and their positional arguments.
+// applied records each filter that was actually used, keyed by query parameter,
+// so responses can echo back how the server interpreted the request.
+type movieFilter struct {
+	clauses []string
+	args    []interface{}
+	applied map[string]interface{}
+}
+
+// arg records a query argument and returns its placeholder number,
+// so a single argument can be referenced by several conditions
+func (f *movieFilter) arg(value interface{}) int {
+	f.args = append(f.args, value)
+	return len(f.args)
+}
+
+// where adds a condition that must hold for every returned row
+func (f *movieFilter) where(clause string) {
+	f.clauses = append(f.clauses, clause)
+}
+
+// whereSQL renders the accumulated conditions, or "" when there are none
+func (f *movieFilter) whereSQL() string {
+	if len(f.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.clauses, " AND ")
+}
+
+// text columns matched by the free-text search parameter
+var searchColumns = []string{"title", "genre", "language", "country", "description"}
+
+// searchClause ORs an ILIKE match across columns, all sharing one placeholder
+func searchClause(columns []string, placeholder int) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = ilikeClause(column, placeholder)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// archivedCondition hides archived movies from a listing or aggregate the way GET /movies does,
+// unless includeArchived=true asks for them too. column is archived, qualified with the table
+// alias when the query needs one.
+func archivedCondition(c *gin.Context, column string) string {
+	if include, _ := strconv.ParseBool(c.Query("includeArchived")); include {
+		return "TRUE"
+	}
+	return "NOT " + column
+}
+
+// genre filter value matching movies with no genre, the same as hasGenre=false
+const noGenreFilter = "__none__"
+
+// conditions matching incomplete records for the hasGenre, hasYear and hasRating filters
+var missingValueClauses = map[string]string{
+	"hasGenre":  "(genre IS NULL OR genre = '')",
+	"hasYear":   "(year IS NULL)",
+	"hasRating": "(rating IS NULL)",
+}
+
+// parseMovieFilters reads the search and filter query parameters shared by the list endpoints,
+// skipping any parameters named in exclude.
+// Unparseable numeric and boolean filters are ignored; an invalid updatedSince is an error
+// since silently dropping it would turn a delta sync into a full one.
+func parseMovieFilters(c *gin.Context, exclude ...string) (*movieFilter, error) {
+	filter := &movieFilter{applied: map[string]interface{}{}}
+	query := func(param string) string {
+		for _, excluded := range exclude {
+			if param == excluded {
+				return ""
+			}
+		}
+		return c.Query(param)
+	}
+
+	// searchOriginalTitle=true also matches the search against original titles
+	if searchQuery := query("search"); searchQuery != "" {
+		columns := searchColumns
+		if includeOriginal, _ := strconv.ParseBool(query("searchOriginalTitle")); includeOriginal {
+			columns = append([]string{"original_title"}, columns...)
+			filter.applied["searchOriginalTitle"] = true
+		}
+		filter.where(searchClause(columns, filter.arg(containsPattern(searchQuery))))
+		filter.applied["search"] = searchQuery
+	}
+	if originalTitleFilter := query("originalTitle"); originalTitleFilter != "" {
+		filter.where(ilikeClause("original_title", filter.arg(containsPattern(originalTitleFilter))))
+		filter.applied["originalTitle"] = originalTitleFilter
+	}
+	if genreFilter := query("genre"); genreFilter == noGenreFilter {
+		filter.where(missingValueClauses["hasGenre"])
+		filter.applied["genre"] = genreFilter
+	} else if genreFilter != "" {
+		filter.where(fmt.Sprintf(`genre ILIKE $%d ESCAPE '\'`, filter.arg(containsPattern(genreFilter))))
+		filter.applied["genre"] = genreFilter
+	}
+	if languageFilter := query("language"); languageFilter != "" {
+		filter.where(fmt.Sprintf(`language ILIKE $%d ESCAPE '\'`, filter.arg(containsPattern(languageFilter))))
+		filter.applied["language"] = languageFilter
+	}
+	if countryFilter := query("country"); countryFilter != "" {
+		filter.where(fmt.Sprintf(`country ILIKE $%d ESCAPE '\'`, filter.arg(containsPattern(countryFilter))))
+		filter.applied["country"] = countryFilter
+	}
+	if yearFilterStr := query("year"); yearFilterStr != "" {
+		if yearFilter, err := strconv.Atoi(yearFilterStr); err == nil {
+			filter.where(fmt.Sprintf("year = $%d", filter.arg(yearFilter)))
+			filter.applied["year"] = yearFilter
+		}
+	}
+	if minRuntimeStr := query("minRuntime"); minRuntimeStr != "" {
+		if minRuntime, err := strconv.Atoi(minRuntimeStr); err == nil {
+			filter.where(fmt.Sprintf("runtime_minutes >= $%d", filter.arg(minRuntime)))
+			filter.applied["minRuntime"] = minRuntime
+		}
+	}
+	if maxRuntimeStr := query("maxRuntime"); maxRuntimeStr != "" {
+		if maxRuntime, err := strconv.Atoi(maxRuntimeStr); err == nil {
+			filter.where(fmt.Sprintf("runtime_minutes <= $%d", filter.arg(maxRuntime)))
+			filter.applied["maxRuntime"] = maxRuntime
+		}
+	}
+	if favoriteFilterStr := query("favorite"); favoriteFilterStr != "" {
+		if favoriteFilter, err := strconv.ParseBool(favoriteFilterStr); err == nil {
+			filter.where(fmt.Sprintf("favorite = $%d", filter.arg(favoriteFilter)))
+			filter.applied["favorite"] = favoriteFilter
+		}
+	}
+	if watchedFilterStr := query("watched"); watchedFilterStr != "" {
+		if watchedFilter, err := strconv.ParseBool(watchedFilterStr); err == nil {
+			filter.where(fmt.Sprintf("watched = $%d", filter.arg(watchedFilter)))
+			filter.applied["watched"] = watchedFilter
+		}
+	}
+	// archived movies are hidden unless archived=true (only them) or includeArchived=true (everything)
+	if archived, err := strconv.ParseBool(query("archived")); err == nil {
+		filter.where(fmt.Sprintf("archived = $%d", filter.arg(archived)))
+		filter.applied["archived"] = archived
+	} else if includeArchived, _ := strconv.ParseBool(query("includeArchived")); includeArchived {
+		filter.applied["includeArchived"] = true
+	} else {
+		filter.where("NOT archived")
+	}
+	for _, param := range []string{"hasGenre", "hasYear", "hasRating"} {
+		if has, err := strconv.ParseBool(query(param)); err == nil {
+			if has {
+				filter.where("NOT " + missingValueClauses[param])
+			} else {
+				filter.where(missingValueClauses[param])
+			}
+			filter.applied[param] = has
+		}
+	}
+	// unrated=true is the "rate these next" queue, the same rows as hasRating=false
+	if unrated, err := strconv.ParseBool(query("unrated")); err == nil && unrated {
+		filter.where(missingValueClauses["hasRating"])
+		filter.applied["unrated"] = true
+	}
+	if collectionStr := query("collection"); collectionStr != "" {
+		if collectionID, err := strconv.Atoi(collectionStr); err == nil {
+			filter.where(fmt.Sprintf("collection_id = $%d", filter.arg(collectionID)))
+			filter.applied["collection"] = collectionID
+		}
+	}
+	if updatedSinceStr := query("updatedSince"); updatedSinceStr != "" {
+		updatedSince, err := time.Parse(time.RFC3339, updatedSinceStr)
+		if err != nil {
+			return nil, fmt.Errorf("updatedSince must be an RFC3339 timestamp")
+		}
+		filter.where(fmt.Sprintf("updated_at > $%d", filter.arg(updatedSince)))
+		filter.applied["updatedSince"] = updatedSince
+	}
+
+	return filter, nil
+}
+
+// largest page size a client may request; use all=true to fetch everything
+const maxPageSize = 100
+
+// pageParams reads page and pageSize from the query, falling back to page 1 and
+// defaultPageSize and capping pageSize at maxPageSize
+func pageParams(c *gin.Context, defaultPageSize int) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.Query("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, maxPageSize)
+}
+
+// pageCount returns the number of pages needed for total items. There is always at least
+// one page, so an empty result is page 1 of 1 (with isEmpty set) rather than an invalid page.
+func pageCount(total, pageSize int) int {
+	if total <= 0 || pageSize <= 0 {
+		return 1
+	}
+	return (total + pageSize - 1) / pageSize
+}
+
+// trustedProxyNets are the TRUSTED_PROXIES networks, set by setupRouter
+var trustedProxyNets []*net.IPNet
+
+// parseTrustedProxies reads TRUSTED_PROXIES entries the way gin does: an IP, or a CIDR
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy IP %q", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxy = fmt.Sprintf("%s/%d", ip, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// fromTrustedProxy reports whether the request came directly from one of TRUSTED_PROXIES
+func fromTrustedProxy(c *gin.Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	for _, ipNet := range trustedProxyNets {
+		if ip != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestScheme is the scheme the client used: https for a TLS connection, or what a
+// trusted proxy reports in X-Forwarded-Proto. The header is ignored from anyone else,
+// so clients can't choose the scheme of generated links.
+func requestScheme(c *gin.Context) string {
+	if fromTrustedProxy(c) {
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// pageURL builds an absolute URL for the current request with only the page parameter changed
+func pageURL(c *gin.Context, page int) string {
+	scheme := requestScheme(c)
+
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	u := url.URL{Scheme: scheme, Host: c.Request.Host, Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// pageLinks returns next/prev URLs for a paginated response; each is nil when there is no such page
+func pageLinks(c *gin.Context, page, totalPages int) gin.H {
+	var next, prev *string
+	if page < totalPages {
+		u := pageURL(c, page+1)
+		next = &u
+	}
+	if page > 1 {
+		// a page past the end links back to the last real page
+		u := pageURL(c, min(page-1, totalPages))
+		prev = &u
+	}
+	return gin.H{"next": next, "prev": prev}
+}
+
+// getMovies handles listing, searching, filtering, and pagination of movies
+func getMovies(c *gin.Context) {
+	page, pageSize := pageParams(c, min(envInt("DEFAULT_PAGE_SIZE", 8), maxPageSize))
+	fetchAll, _ := strconv.ParseBool(c.Query("all"))
+
+	offset := (page - 1) * pageSize
+
+	filter, err := parseMovieFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	whereSQL := filter.whereSQL()
+	filterArgs := filter.args
+	filterArgCount := len(filterArgs) + 1
+
+	// fields=id,title,... trims each movie down to the listed fields
+	selectList := movieColumns
+	var fields []movieField
+	if rawFields := c.Query("fields"); rawFields != "" {
+		if fields, err = parseMovieFields(rawFields); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		selectList = fieldSelectList(fields)
+	}
+
+	orderBy := movieOrderBy(filter)
+
+	total, err := countMovies(filter)
+	if err != nil {
+		log.Printf("Error counting total movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count movies", "details": err.Error()})
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	// Build the arguments for the main SELECT query
+	selectArgs := make([]interface{}, len(filterArgs))
+	copy(selectArgs, filterArgs)
+
+	var querySQL string
+	if fetchAll {
+		// all=true returns the whole filtered set as a single page, within a safety cap
+		maxFetchAll := envInt("FETCH_ALL_MAX", 10000)
+		if total > maxFetchAll {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Too many movies to fetch at once (%d, limit %d); use pagination instead", total, maxFetchAll),
+				"total": total,
+				"limit": maxFetchAll,
+			})
+			return
+		}
+		// an empty result keeps the requested page size so clients never divide by zero
+		page = 1
+		if total > 0 {
+			pageSize = total
+		}
+		querySQL = fmt.Sprintf("SELECT %s FROM movies %s ORDER BY %s", selectList, whereSQL, orderBy)
+	} else {
+		// for OFFSET and LIMIT
+		offsetPlaceholder := filterArgCount
+		limitPlaceholder := filterArgCount + 1
 
-var db *sql.DB
+		// SELECT query string
+		querySQL = fmt.Sprintf("SELECT %s FROM movies %s ORDER BY %s OFFSET $%d LIMIT $%d",
+			selectList, whereSQL, orderBy, offsetPlaceholder, limitPlaceholder)
 
-// initializes the PostgreSQL
-func initDB() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("Warning: Could not load .env file.%v", err)
+		// Append OFFSET and LIMIT values to the selectArgs
+		selectArgs = append(selectArgs, offset, pageSize)
 	}
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		log.Fatalf("Fatal: DATABASE_URL environment variable is not set.")
+
+	var movies interface{}
+	if fields != nil {
+		movies, err = queryMovieFields(querySQL, fields, selectArgs...)
 	} else {
-		log.Println("DATABASE_URL successfully loaded from environment.")
+		movies, err = queryMovies(querySQL, selectArgs...)
 	}
-
-	var openErr error
-	db, openErr = sql.Open("postgres", connStr)
-	if openErr != nil {
-		log.Fatalf("Error opening database connection with string '%s': %v", connStr, openErr)
+	if err != nil {
+		log.Printf("Error fetching movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies", "details": err.Error()})
+		return
 	}
 
-	pingErr := db.Ping()
-	if pingErr != nil {
-		log.Fatalf("Error connecting to the database with string '%s': %v", connStr, pingErr)
+	totalPages := 1
+	if !fetchAll {
+		totalPages = pageCount(total, pageSize)
 	}
 
-	log.Println("Successfully connected to PostgreSQL database!")
+	respond(c, http.StatusOK, gin.H{
+		"movies":         movies,
+		"total":          total,
+		"page":           page,
+		"pageSize":       pageSize,
+		"totalPages":     totalPages,
+		"isEmpty":        total == 0,
+		"hasMore":        page < totalPages,
+		"links":          pageLinks(c, page, totalPages),
+		"appliedFilters": filter.applied,
+	})
+}
 
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS movies (
-		id SERIAL PRIMARY KEY,
-		title VARCHAR(255) NOT NULL UNIQUE,
-		genre VARCHAR(100),
-		year INT,
-		rating INT CHECK (rating >= 0 AND rating <= 5)
-	);`
-	_, err = db.Exec(createTableSQL)
-	if err != nil {
-		log.Fatalf("Error creating movies table: %v", err)
+// movieOrderBy is the ORDER BY list for the movies GET /movies returns under filter.
+// The unrated queue surfaces the longest-waiting movies first. Whatever the sort keys,
+// id is appended as the final tiebreaker so tied rows keep their place across pages.
+func movieOrderBy(filter *movieFilter) string {
+	sortKeys := []string{}
+	if filter.applied["unrated"] == true {
+		sortKeys = append(sortKeys, "created_at")
 	}
-	log.Println("Movies table checked or created.")
+	return strings.Join(append(sortKeys, "id ASC"), ", ")
 }
 
-// create
-func createMovie(c *gin.Context) {
-	var movie Movie
-	if err := c.ShouldBindJSON(&movie); err != nil {
+// the ID and title of a movie adjacent to another in a listing
+type MovieNeighbor struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// getMovieNeighbors returns the movies just before and after a movie in the GET /movies
+// ordering for the same filters, for previous/next navigation. Either is null at the ends
+// of the list, and a movie the filters exclude is a 404 like one that doesn't exist.
+func getMovieNeighbors(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+	filter, err := parseMovieFilters(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Validating year
-	currentYear := time.Now().Year()
-	if movie.Year < 1900 || movie.Year > currentYear {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Year must be between 1900 and %d", currentYear)})
+	args := append(filter.args, id)
+	querySQL := fmt.Sprintf(`SELECT prev_id, prev_title, next_id, next_title FROM (
+		SELECT id,
+			LAG(id) OVER listing AS prev_id, LAG(title) OVER listing AS prev_title,
+			LEAD(id) OVER listing AS next_id, LEAD(title) OVER listing AS next_title
+		FROM movies %s
+		WINDOW listing AS (ORDER BY %s)
+	) AS listed WHERE id = $%d`, filter.whereSQL(), movieOrderBy(filter), len(args))
+
+	var prevID, nextID *int
+	var prevTitle, nextTitle *string
+	err = db.QueryRow(querySQL, args...).Scan(&prevID, &prevTitle, &nextID, &nextTitle)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound, gin.H{"appliedFilters": filter.applied}))
 		return
 	}
-
-	// Checking for duplicate title
-	var exists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM movies WHERE title ILIKE $1)", movie.Title).Scan(&exists)
 	if err != nil {
-		log.Printf("Error checking for duplicate title: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+		log.Printf("Error fetching movie neighbors: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch neighboring movies", "details": err.Error()})
 		return
 	}
-	if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Movie with this title already exists"})
-		return
+
+	var previous, next *MovieNeighbor
+	if prevID != nil {
+		previous = &MovieNeighbor{ID: *prevID, Title: *prevTitle}
+	}
+	if nextID != nil {
+		next = &MovieNeighbor{ID: *nextID, Title: *nextTitle}
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "previous": previous, "next": next, "appliedFilters": filter.applied})
+}
+
+// getRecentMovies returns the most recently added movies, optionally within a genre.
+// limit defaults to 10 and is capped at 50 since this backs the "What's New" widget.
+func getRecentMovies(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
 	}
 
-	err = db.QueryRow(
-		"INSERT INTO movies (title, genre, year, rating) VALUES ($1, $2, $3, $4) RETURNING id",
-		movie.Title, movie.Genre, movie.Year, movie.Rating,
-	).Scan(&movie.ID)
+	whereSQL := " WHERE " + archivedCondition(c, "archived")
+	args := []interface{}{}
+	if genreFilter := c.Query("genre"); genreFilter != "" {
+		whereSQL += ` AND genre ILIKE $1 ESCAPE '\'`
+		args = append(args, containsPattern(genreFilter))
+	}
+	args = append(args, limit)
 
+	querySQL := fmt.Sprintf("SELECT %s FROM movies %s ORDER BY created_at DESC, id DESC LIMIT $%d",
+		movieColumns, whereSQL, len(args))
+
+	movies, err := queryMovies(querySQL, args...)
 	if err != nil {
-		log.Printf("Error inserting movie: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+		log.Printf("Error fetching recent movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recent movies", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, movie)
+	respond(c, http.StatusOK, gin.H{"movies": movies, "limit": limit})
 }
 
-// updateMovie handles updating an existing movie
-func updateMovie(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+// TitleSuggestion is one entry of the type-ahead list: just enough to show and link a title
+type TitleSuggestion struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// suggestTitles backs search-box autocomplete: titles starting with q, best rated first.
+// limit defaults to 5 and is capped at 20; archived movies are never suggested.
+func suggestTitles(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
 		return
 	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		limit = 5
+	}
+	if limit > 20 {
+		limit = 20
+	}
 
-	var input UpdateMovieInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	rows, err := db.Query(`SELECT id, title FROM movies
+		WHERE title ILIKE $1 ESCAPE '\' AND NOT archived
+		ORDER BY rating DESC NULLS LAST, title, id LIMIT $2`, escapeLike(prefix)+"%", limit)
+	if err != nil {
+		log.Printf("Error fetching title suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions", "details": err.Error()})
 		return
 	}
+	defer rows.Close()
 
-	setClauses := []string{}
-	args := []interface{}{}
-	argCount := 1
-
-	if input.Title != nil {
-		var existingID int
-		err := db.QueryRow("SELECT id FROM movies WHERE title ILIKE $1 AND id != $2", *input.Title, id).Scan(&existingID)
-		if err != nil && err != sql.ErrNoRows {
-			log.Printf("Error checking for duplicate title on update: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
-			return
-		}
-		if existingID != 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "Movie with this title already exists"})
+	suggestions := []TitleSuggestion{}
+	for rows.Next() {
+		var suggestion TitleSuggestion
+		if err := rows.Scan(&suggestion.ID, &suggestion.Title); err != nil {
+			log.Printf("Error scanning title suggestion: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions", "details": err.Error()})
 			return
 		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating title suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions", "details": err.Error()})
+		return
+	}
 
-		setClauses = append(setClauses, fmt.Sprintf("title = $%d", argCount))
-		args = append(args, *input.Title)
-		argCount++
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// getSimilarMovies suggests related titles for a movie. The heuristic is deliberately simple:
+// other movies with the same genre, released within yearRange years of it (default 10,
+// 0 disables the year constraint), best rated first and then closest in year.
+// A movie without a genre has no suggestions.
+func getSimilarMovies(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
 	}
-	if input.Genre != nil {
-		setClauses = append(setClauses, fmt.Sprintf("genre = $%d", argCount))
-		args = append(args, *input.Genre)
-		argCount++
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		limit = 5
 	}
-	if input.Year != nil {
-		currentYear := time.Now().Year()
-		if *input.Year < 1900 || *input.Year > currentYear {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Year must be between 1900 and %d", currentYear)})
-			return
-		}
-		setClauses = append(setClauses, fmt.Sprintf("year = $%d", argCount))
-		args = append(args, *input.Year)
-		argCount++
+	if limit > 20 {
+		limit = 20
 	}
-	if input.Rating != nil {
-		if *input.Rating < 0 || *input.Rating > 5 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be between 0 and 5"})
+	yearRange, err := strconv.Atoi(c.DefaultQuery("yearRange", "10"))
+	if err != nil || yearRange < 0 {
+		yearRange = 10
+	}
+
+	source, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
 			return
 		}
-		setClauses = append(setClauses, fmt.Sprintf("rating = $%d", argCount))
-		args = append(args, *input.Rating)
-		argCount++
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+	if source.Genre == "" {
+		respond(c, http.StatusOK, gin.H{"movies": []Movie{}})
+		return
+	}
+	if source.Year == nil {
+		yearRange = 0 // nothing to be close to
 	}
 
-	if len(setClauses) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update provided"})
+	querySQL := fmt.Sprintf(`SELECT %s FROM movies
+	WHERE id != $1 AND LOWER(genre) = LOWER($2) AND ($3 = 0 OR year BETWEEN $4 - $3 AND $4 + $3) AND %s
+	ORDER BY COALESCE(rating_average, rating) DESC NULLS LAST, ABS(year - $4), id
+	LIMIT $5`, movieColumns, archivedCondition(c, "archived"))
+	movies, err := queryMovies(querySQL, source.ID, source.Genre, yearRange, source.Year, limit)
+	if err != nil {
+		log.Printf("Error fetching similar movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch similar movies", "details": err.Error()})
 		return
 	}
 
-	args = append(args, id) // Add ID as the last argument for the WHERE clause
-	query := fmt.Sprintf("UPDATE movies SET %s WHERE id = $%d RETURNING id", strings.Join(setClauses, ", "), argCount)
+	respond(c, http.StatusOK, gin.H{"movies": movies})
+}
 
-	var updatedID int
-	err = db.QueryRow(query, args...).Scan(&updatedID)
+// getWatchlist returns the favorited movies in the order they were favorited, oldest first
+func getWatchlist(c *gin.Context) {
+	movies, err := queryMovies(fmt.Sprintf("SELECT %s FROM movies WHERE favorite AND %s ORDER BY favorited_at, id",
+		movieColumns, archivedCondition(c, "archived")))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
-			return
-		}
-		log.Printf("Error updating movie: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie", "details": err.Error()})
+		log.Printf("Error fetching watchlist: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watchlist", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Movie updated successfully", "id": updatedID})
+	respond(c, http.StatusOK, gin.H{"movies": movies, "total": len(movies)})
 }
 
-// getMovies handles listing, searching, filtering, and pagination of movies
-func getMovies(c *gin.Context) {
-	searchQuery := c.Query("search")
-	genreFilter := c.Query("genre")
-	yearFilterStr := c.Query("year")
-	pageStr := c.DefaultQuery("page", "1")
-	pageSizeStr := c.DefaultQuery("pageSize", "8")
+// countMovies returns how many movies match the filter
+func countMovies(filter *movieFilter) (int, error) {
+	var total int
+	err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM movies %s", filter.whereSQL()), filter.args...).Scan(&total)
+	return total, err
+}
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
-	pageSize, err := strconv.Atoi(pageSizeStr)
-	if err != nil || pageSize < 1 {
-		pageSize = 8
+// headMovies answers HEAD /movies with the filtered total in X-Total-Count and no body,
+// so availability checks and clients that only need the count stay cheap
+func headMovies(c *gin.Context) {
+	filter, err := parseMovieFilters(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
 	}
 
-	offset := (page - 1) * pageSize
+	total, err := countMovies(filter)
+	if err != nil {
+		log.Printf("Error counting total movies: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
 
-	// Build filter clauses and arguments
-	filterClauses := []string{}
-	filterArgs := []interface{}{}
-	filterArgCount := 1
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.Status(http.StatusOK)
+}
 
-	if searchQuery != "" {
-		filterClauses = append(filterClauses, fmt.Sprintf("title ILIKE $%d", filterArgCount))
-		filterArgs = append(filterArgs, "%"+searchQuery+"%")
-		filterArgCount++
-	}
-	if genreFilter != "" {
-		filterClauses = append(filterClauses, fmt.Sprintf("genre ILIKE $%d", filterArgCount))
-		filterArgs = append(filterArgs, "%"+genreFilter+"%")
-		filterArgCount++
+// getTopMovies returns the highest-rated movies by averageRating, optionally within a genre.
+// limit defaults to 10 and is capped at 50 since this backs homepage widgets.
+func getTopMovies(c *gin.Context) {
+	genreFilter := c.Query("genre")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
 	}
-	if yearFilterStr != "" {
-		yearFilter, err := strconv.Atoi(yearFilterStr)
-		if err == nil {
-			filterClauses = append(filterClauses, fmt.Sprintf("year = $%d", filterArgCount))
-			filterArgs = append(filterArgs, yearFilter)
-			filterArgCount++
-		}
+	if limit > 50 {
+		limit = 50
 	}
 
-	whereSQL := ""
-	if len(filterClauses) > 0 {
-		whereSQL = " WHERE " + strings.Join(filterClauses, " AND ")
+	whereSQL := " WHERE " + archivedCondition(c, "archived")
+	args := []interface{}{}
+	if genreFilter != "" {
+		whereSQL += ` AND genre ILIKE $1 ESCAPE '\'`
+		args = append(args, containsPattern(genreFilter))
 	}
+	args = append(args, limit)
 
-	totalMoviesQuery := fmt.Sprintf("SELECT COUNT(*) FROM movies %s", whereSQL)
-	var total int
-	log.Printf("DEBUG: Count Query: %s, Args: %+v", totalMoviesQuery, filterArgs) // Use filterArgs for COUNT
-	err = db.QueryRow(totalMoviesQuery, filterArgs...).Scan(&total)
+	querySQL := fmt.Sprintf("SELECT %s FROM movies %s ORDER BY COALESCE(rating_average, rating) DESC NULLS LAST, year DESC, id LIMIT $%d",
+		movieColumns, whereSQL, len(args))
+
+	movies, err := queryMovies(querySQL, args...)
 	if err != nil {
-		log.Printf("Error counting total movies: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count movies", "details": err.Error()})
+		log.Printf("Error fetching top movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top movies", "details": err.Error()})
 		return
 	}
 
-	// Build the arguments for the main SELECT query
-	selectArgs := make([]interface{}, len(filterArgs))
-	copy(selectArgs, filterArgs)
-
-	// for OFFSET and LIMIT
-	offsetPlaceholder := filterArgCount
-	limitPlaceholder := filterArgCount + 1
-
-	// SELECT query string
-	querySQL := fmt.Sprintf("SELECT id, title, genre, year, rating FROM movies %s ORDER BY id OFFSET $%d LIMIT $%d",
-		whereSQL, offsetPlaceholder, limitPlaceholder)
+	respond(c, http.StatusOK, gin.H{"movies": movies, "limit": limit})
+}
 
-	// Append OFFSET and LIMIT values to the selectArgs
-	selectArgs = append(selectArgs, offset, pageSize)
+// cloneTitle picks the first free title of the form "<title> (Copy)", "<title> (Copy 2)", ...
+// truncating the original title if needed to stay within maxTitleLength
+func cloneTitle(title string) (string, error) {
+	for n := 1; ; n++ {
+		suffix := " (Copy)"
+		if n > 1 {
+			suffix = fmt.Sprintf(" (Copy %d)", n)
+		}
+		base := []rune(title)
+		if room := maxTitleLength - utf8.RuneCountInString(suffix); len(base) > room {
+			base = base[:room]
+		}
+		candidate := string(base) + suffix
 
-	log.Printf("DEBUG: Select Query: %s, Args: %+v", querySQL, selectArgs)
+		exists, err := titleExists(db, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
 
-	rows, err := db.Query(querySQL, selectArgs...)
+// cloneMovie creates a copy of an existing movie under a new, unique title
+func cloneMovie(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		log.Printf("Error fetching movies: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
 		return
 	}
-	defer rows.Close()
 
-	movies := []Movie{}
-	for rows.Next() {
-		var movie Movie
-		if err := rows.Scan(&movie.ID, &movie.Title, &movie.Genre, &movie.Year, &movie.Rating); err != nil {
-			log.Printf("Error scanning movie row: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan movie data", "details": err.Error()})
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
 			return
 		}
-		movies = append(movies, movie)
+		log.Printf("Error fetching movie to clone: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Error after iterating rows: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movies", "details": err.Error()})
+	movie.Title, err = cloneTitle(movie.Title)
+	if err != nil {
+		log.Printf("Error checking for duplicate title: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"movies":     movies,
-		"total":      total,
-		"page":       page,
-		"pageSize":   pageSize,
-		"totalPages": (total + pageSize - 1) / pageSize,
-	})
+	if err := insertMovie(db, &movie); err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+			return
+		}
+		log.Printf("Error inserting cloned movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone movie", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieCreated, movie.ID)
+
+	c.Header("Location", movieLocation(movie.ID))
+	c.JSON(http.StatusCreated, movie)
+}
+
+// toggleFlag returns a handler that flips a boolean column on a movie and returns the new
+// state under key. column must be a trusted identifier, never user input.
+func toggleFlag(column, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+			return
+		}
+
+		var value bool
+		query := fmt.Sprintf("UPDATE movies SET %[1]s = NOT %[1]s, version = version + 1 WHERE id = $1 RETURNING %[1]s", column)
+		err = db.QueryRow(query, id).Scan(&value)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+				return
+			}
+			log.Printf("Error toggling %s: %v", column, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle " + key, "details": err.Error()})
+			return
+		}
+		movieEvents.publish(movieUpdated, id)
+
+		c.JSON(http.StatusOK, gin.H{"id": id, key: value})
+	}
 }
 
 // deleting a movie by ID
@@ -316,7 +1828,7 @@ func deleteMovie(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
 		return
 	}
 
@@ -335,30 +1847,263 @@ func deleteMovie(c *gin.Context) {
 	}
 
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
 		return
 	}
+	movieEvents.publish(movieDeleted, id)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Movie deleted successfully"})
 }
 
-func main() {
-	initDB()
-	defer db.Close()
+// envList reads a comma-separated list from an environment variable,
+// returning fallback when it is unset or empty
+func envList(name string, fallback []string) []string {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	values := []string{}
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+// envInt reads a positive integer from an environment variable,
+// returning fallback when it is unset or invalid
+func envInt(name string, fallback int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s %q, using %d", name, raw, fallback)
+		return fallback
+	}
+	return n
+}
+
+// envDuration reads a Go duration such as "24h" from an environment variable,
+// returning fallback when it is unset or invalid
+func envDuration(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", name, raw, fallback)
+		return fallback
+	}
+	return d
+}
+
+// newServer wraps the router in an http.Server with timeouts so slow clients
+// can't hold connections open indefinitely
+func newServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: envDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       envDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      serverWriteTimeout(),
+		IdleTimeout:       envDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+	}
+}
+
+// serverWriteTimeout is how long a handler has to write its response, from SERVER_WRITE_TIMEOUT
+func serverWriteTimeout() time.Duration {
+	return envDuration("SERVER_WRITE_TIMEOUT", 30*time.Second)
+}
+
+// apiBasePath returns the BASE_PATH route prefix normalized to "/a/b" form, or "" when unset
+func apiBasePath() string {
+	path := strings.Trim(strings.TrimSpace(os.Getenv("BASE_PATH")), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// movieLocation returns the path of a movie resource, for Location headers
+func movieLocation(id int) string {
+	return fmt.Sprintf("%s/%s/movies/%d", apiBasePath(), apiVersion, id)
+}
+
+// registerAPIRoutes registers the movie API on group
+func registerAPIRoutes(group *gin.RouterGroup) {
+	group.POST("/movies", createMovie)
+	group.POST("/movies/from-omdb", importMovieFromOMDb)
+	group.POST("/movies/batch-delete", batchDeleteMovies)
+	group.POST("/movies/merge", mergeMovies)
+	group.POST("/movies/validate", validateMovie)
+	group.POST("/movies/by-ids", getMoviesByIDs)
+	group.POST("/movies/bulk-rating", bulkUpdateRating)
+	group.POST("/movies/import.json", importMoviesJSON)
+	group.POST("/movies/import.csv", importMoviesCSV)
+	group.POST("/movies/import/letterboxd", importLetterboxd)
+	group.GET("/movies", presetMiddleware(), getMovies)
+	group.HEAD("/movies", presetMiddleware(), headMovies)
+	group.GET("/movies/top", getTopMovies)
+	group.GET("/movies/recent", getRecentMovies)
+	group.GET("/movies/trending", getTrendingMovies)
+	group.GET("/movies/schema", getMovieSchema)
+	group.GET("/movies/suggest", suggestTitles)
+	group.GET("/movies/export.json", presetMiddleware(), exportMoviesJSON)
+	group.GET("/movies/stats/genres", statsCache.cached(), getGenreStats)
+	group.GET("/movies/stats/ratings", statsCache.cached(), getRatingStats)
+	group.GET("/movies/stats/decades", statsCache.cached(), getDecadeStats)
+	group.GET("/movies/stats/timeline", statsCache.cached(), getTimelineStats)
+	group.GET("/movies/stats/report", statsCache.cached(), getStatsReport)
+	group.GET("/movies/compare", compareMovies)
+	group.GET("/movies/changes", getMovieChanges)
+	group.GET("/movies/by-title", getMovieByTitle)
+	group.GET("/movies/languages", distinctHandler("language", "languages"))
+	group.GET("/movies/countries", distinctHandler("country", "countries"))
+	group.GET("/movies/genres", distinctHandler("genre", "genres"))
+	group.GET("/movies/years", getYears)
+	group.GET("/movies/facets", presetMiddleware(), getMovieFacets)
+	group.GET("/movies/stream", streamMovies)
+	group.GET("/watchlist", getWatchlist)
+	group.GET("/movies/:id", getMovie)
+	group.GET("/movies/:id/similar", getSimilarMovies)
+	group.GET("/movies/:id/neighbors", presetMiddleware(), getMovieNeighbors)
+	group.GET("/movies/:id/share", getMovieShare)
+	group.GET("/movies/:id/history", getMovieHistory)
+	group.GET("/movies/:id/ratings", getMovieRatings)
+	group.GET("/movies/:id/reviews", getMovieReviews)
+	group.PUT("/movies/batch", batchUpdateMovies)
+	group.PUT("/movies/:id", updateMovie)
+	group.PUT("/movies/:id/replace", replaceMovie)
+	group.PUT("/movies/:id/rating", setUserRating)
+	group.DELETE("/movies/:id", deleteMovie)
+	group.POST("/movies/:id/favorite", toggleFlag("favorite", "favorite"))
+	group.POST("/movies/:id/watched", toggleFlag("watched", "watched"))
+	group.POST("/movies/:id/archive", toggleFlag("archived", "archived"))
+	group.POST("/movies/:id/clone", cloneMovie)
+	group.POST("/movies/:id/view", recordView)
+	group.POST("/movies/:id/sync-tmdb", syncMovieFromTMDb)
+	group.POST("/movies/:id/reviews", createReview)
+	group.DELETE("/reviews/:id", deleteReview)
+	group.POST("/tags/:tag/movies", tagMovies)
+	group.POST("/presets", createPreset)
+	group.GET("/presets", getPresets)
+	group.DELETE("/presets/:name", deletePreset)
+	group.POST("/collections", createCollection)
+	group.GET("/collections", getCollections)
+	group.GET("/collections/:id/movies", getCollectionMovies)
+	group.POST("/collections/:id/movies", addCollectionMovies)
+	group.DELETE("/collections/:id/movies/:movieId", removeCollectionMovie)
+	group.GET("/s/:token", resolveShareToken)
+	group.POST("/admin/reindex", adminMiddleware(), reindex)
+}
+
+// ginMode picks Gin's mode from GIN_MODE (debug, release or test), falling back to
+// release when ENV is "production" and debug otherwise
+func ginMode() string {
+	switch mode := os.Getenv("GIN_MODE"); mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+		return mode
+	case "":
+	default:
+		log.Printf("Warning: invalid GIN_MODE %q, ignoring it", mode)
+	}
+	if os.Getenv("ENV") == "production" {
+		return gin.ReleaseMode
+	}
+	return gin.DebugMode
+}
+
+// setupRouter configures middleware and registers all routes
+func setupRouter() *gin.Engine {
+	gin.SetMode(ginMode())
+	router := gin.New()
+	router.Use(requestIDMiddleware(), requestLogger(), recoveryMiddleware())
 
-	router := gin.Default()
+	// TRUSTED_PROXIES lists the proxy IPs or CIDRs whose X-Forwarded-For is believed by
+	// c.ClientIP(), and whose X-Forwarded-Proto by requestScheme; when unset no proxy is
+	// trusted and the connection's address and scheme are used
+	trustedProxies := envList("TRUSTED_PROXIES", nil)
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	nets, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	trustedProxyNets = nets
 
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowHeaders = envList("CORS_ALLOW_HEADERS",
+		[]string{"Origin", "Content-Type", "Accept", "If-None-Match", "If-Unmodified-Since", "Authorization", "X-API-Key", "X-User-ID", "Idempotency-Key", "X-Request-ID"})
+	config.ExposeHeaders = []string{"Content-Length", "Location", "ETag", "Last-Modified", "X-Total-Count", "Deprecation", "Link", "X-Request-ID", "X-Rating-Scale"}
 	router.Use(cors.New(config))
 
-	router.POST("/movies", createMovie)
-	router.GET("/movies", getMovies)
-	router.PUT("/movies/:id", updateMovie)
-	router.DELETE("/movies/:id", deleteMovie)
+	// GZIP_LEVEL is 1 (fastest) to 9 (smallest); GZIP_MIN_SIZE is in bytes
+	gzipLevel := envInt("GZIP_LEVEL", gzip.DefaultCompression)
+	if gzipLevel > gzip.BestCompression {
+		log.Printf("Warning: invalid GZIP_LEVEL %d, using the default", gzipLevel)
+		gzipLevel = gzip.DefaultCompression
+	}
+	router.Use(gzipMiddleware(gzipLevel, envInt("GZIP_MIN_SIZE", 1024)))
+
+	// MAX_BODY_SIZE is in bytes and also bounds the import uploads
+	router.Use(bodyLimitMiddleware(int64(envInt("MAX_BODY_SIZE", 10<<20))))
+
+	if readOnly, _ := strconv.ParseBool(os.Getenv("READ_ONLY")); readOnly {
+		log.Println("READ_ONLY is set: all POST, PUT, PATCH and DELETE requests will be rejected.")
+		router.Use(readOnlyMiddleware())
+	}
+
+	// stats responses are cached until STATS_CACHE_TTL passes or the catalogue is written to
+	statsCache = newResponseCache(statsCacheTTL())
+	router.Use(invalidateOnWrite(statsCache))
+
+	router.Use(ratingScaleMiddleware())
+
+	// every API route lives under BASE_PATH; routes meant to stay at the root, such as
+	// health checks, should be registered on router instead
+	base := router.Group(apiBasePath())
+	base.GET("/version", getVersion)
+	registerAPIRoutes(base.Group("/" + apiVersion))
+	// unversioned aliases kept during the deprecation period
+	registerAPIRoutes(base.Group("", deprecatedRouteMiddleware()))
+
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found", "path": c.Request.URL.Path})
+	})
+	router.NoMethod(func(c *gin.Context) {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed", "method": c.Request.Method, "path": c.Request.URL.Path})
+	})
+
+	return router
+}
+
+func main() {
+	seed := flag.Bool("seed", false, "insert demo movies into the database on startup")
+	seedOnly := flag.Bool("seed-only", false, "exit after seeding instead of starting the server (implies -seed)")
+	flag.Parse()
+
+	initDB()
+	defer db.Close()
+
+	if *seed || *seedOnly {
+		seedDemoData()
+		if *seedOnly {
+			return
+		}
+	}
+
+	router := setupRouter()
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -367,8 +2112,10 @@ func main() {
 
 	port = ":" + port
 
+	server := newServer(port, router)
+
 	log.Printf("Server starting on port %s", port)
-	if err := router.Run(port); err != nil {
+	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
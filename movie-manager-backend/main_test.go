@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unique violation", &pq.Error{Code: "23505"}, true},
+		{"wrapped unique violation", fmt.Errorf("inserting movie: %w", &pq.Error{Code: "23505"}), true},
+		{"other pq error", &pq.Error{Code: "23514"}, false},
+		{"non-pq error", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.want {
+				t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRespondDuplicate(t *testing.T) {
+	tests := []struct {
+		language, wantError string
+	}{
+		{"", "Movie with this title already exists"},
+		{"es", "Ya existe una película con este título"},
+	}
+	for _, tt := range tests {
+		t.Run("language "+tt.language, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/movies", nil)
+			if tt.language != "" {
+				c.Request.Header.Set("Accept-Language", tt.language)
+			}
+
+			respondDuplicate(c, "Alien", false)
+
+			if w.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if body["code"] != codeTitleTaken || body["field"] != "title" || body["error"] != tt.wantError {
+				t.Errorf("body = %v, want code %q, field \"title\" and error %q", body, codeTitleTaken, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	tests := []struct {
+		name                  string
+		total, pageSize, want int
+	}{
+		{"no results", 0, 10, 1},
+		{"exact multiple", 20, 10, 2},
+		{"partial last page", 21, 10, 3},
+		{"fewer than a page", 3, 10, 1},
+		{"invalid page size", 5, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageCount(tt.total, tt.pageSize); got != tt.want {
+				t.Errorf("pageCount(%d, %d) = %d, want %d", tt.total, tt.pageSize, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	tests := []struct {
+		name               string
+		page, totalPages   int
+		wantNext, wantPrev string // "" means no link
+	}{
+		{"only page", 1, 1, "", ""},
+		{"first of two", 1, 2, "http://example.com/movies?page=2", ""},
+		{"last page", 2, 2, "", "http://example.com/movies?page=1"},
+		{"past the end", 5, 2, "", "http://example.com/movies?page=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "http://example.com/movies?page=1", nil)
+
+			links := pageLinks(c, tt.page, tt.totalPages)
+			for key, want := range map[string]string{"next": tt.wantNext, "prev": tt.wantPrev} {
+				got, _ := links[key].(*string)
+				switch {
+				case want == "" && got != nil:
+					t.Errorf("%s = %q, want none", key, *got)
+				case want != "" && (got == nil || *got != want):
+					t.Errorf("%s = %v, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestScheme(t *testing.T) {
+	nets, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatalf("parseTrustedProxies: %v", err)
+	}
+	trustedProxyNets = nets
+	defer func() { trustedProxyNets = nil }()
+
+	tests := []struct {
+		name, remoteAddr, proto, want string
+	}{
+		{"no header", "203.0.113.7:1234", "", "http"},
+		{"untrusted client", "203.0.113.7:1234", "https", "http"},
+		{"trusted CIDR", "10.1.2.3:1234", "https", "https"},
+		{"trusted IP", "192.168.1.5:1234", "https", "https"},
+		{"trusted proxy, unknown scheme", "10.1.2.3:1234", "javascript", "http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/movies", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			if tt.proto != "" {
+				c.Request.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if got := requestScheme(c); got != tt.want {
+				t.Errorf("requestScheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
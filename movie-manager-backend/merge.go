@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// request body for merging a duplicate movie into the one being kept
+type MergeMoviesInput struct {
+	KeepID  int `json:"keepId" binding:"required"`
+	MergeID int `json:"mergeId" binding:"required"`
+}
+
+// mergeMovies folds a duplicate movie into another and deletes the duplicate, in one transaction.
+// The kept movie's fields win, except that it stays favorited or watched if either movie was
+// and takes the duplicate's collection if it has none. The duplicate's history, user ratings,
+// reviews, views and tags are moved over to the kept movie.
+func mergeMovies(c *gin.Context) {
+	var input MergeMoviesInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if input.KeepID == input.MergeID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keepId and mergeId must be different movies"})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting merge transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	// lock both rows so neither changes underneath the merge, in id order so that two merges
+	// of the same pair in opposite directions can't deadlock
+	type mergeSide struct {
+		favorite, watched bool
+		collectionID      *int
+	}
+	sides := map[int]*mergeSide{}
+	rows, err := tx.Query("SELECT id, favorite, watched, collection_id FROM movies WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		pq.Array([]int{input.KeepID, input.MergeID}))
+	if err != nil {
+		log.Printf("Error fetching movies to merge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+		return
+	}
+	for rows.Next() {
+		var id int
+		side := &mergeSide{}
+		if err := rows.Scan(&id, &side.favorite, &side.watched, &side.collectionID); err != nil {
+			rows.Close()
+			log.Printf("Error scanning movie to merge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+			return
+		}
+		sides[id] = side
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("Error fetching movies to merge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+		return
+	}
+	for _, id := range []int{input.KeepID, input.MergeID} {
+		if sides[id] == nil {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound, gin.H{"id": id}))
+			return
+		}
+	}
+	keep, merged := sides[input.KeepID], sides[input.MergeID]
+
+	// runs one step of the merge, writing the error response when it fails
+	exec := func(query string, args ...interface{}) bool {
+		if _, err := tx.Exec(query, args...); err != nil {
+			log.Printf("Error merging movies: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+			return false
+		}
+		return true
+	}
+	// ratings move over unless the user already rated the kept movie, in which case that rating wins;
+	// tags the kept movie already has are left to be deleted with the duplicate
+	if !exec("UPDATE movie_history SET movie_id = $1 WHERE movie_id = $2", input.KeepID, input.MergeID) ||
+		!exec(`UPDATE movie_ratings SET movie_id = $1 WHERE movie_id = $2
+		AND user_id NOT IN (SELECT user_id FROM movie_ratings WHERE movie_id = $1)`, input.KeepID, input.MergeID) ||
+		!exec("UPDATE movie_reviews SET movie_id = $1 WHERE movie_id = $2", input.KeepID, input.MergeID) ||
+		!exec("UPDATE movie_views SET movie_id = $1 WHERE movie_id = $2", input.KeepID, input.MergeID) ||
+		!exec(`UPDATE movie_tags SET movie_id = $1 WHERE movie_id = $2
+		AND tag_id NOT IN (SELECT tag_id FROM movie_tags WHERE movie_id = $1)`, input.KeepID, input.MergeID) ||
+		!exec("DELETE FROM movies WHERE id = $1", input.MergeID) ||
+		!exec("INSERT INTO movie_history (movie_id, action, changes) VALUES ($1, 'merge', jsonb_build_object('mergedId', $2::int))",
+			input.KeepID, input.MergeID) {
+		return
+	}
+	// the duplicate's collection is kept when the kept movie is in none
+	collectionID := keep.collectionID
+	if collectionID == nil {
+		collectionID = merged.collectionID
+	}
+	if (merged.favorite && !keep.favorite) || (merged.watched && !keep.watched) || collectionID != keep.collectionID {
+		if !exec("UPDATE movies SET favorite = $1, watched = $2, collection_id = $3, version = version + 1 WHERE id = $4",
+			keep.favorite || merged.favorite, keep.watched || merged.watched, collectionID, input.KeepID) {
+			return
+		}
+	}
+
+	var movie Movie
+	if err := scanMovie(tx.QueryRow(fmt.Sprintf("SELECT %s FROM movies WHERE id = $1", movieColumns), input.KeepID), &movie); err != nil {
+		log.Printf("Error fetching merged movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing merge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge movies", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieDeleted, input.MergeID)
+	movieEvents.publish(movieUpdated, input.KeepID)
+
+	c.JSON(http.StatusOK, movie)
+}
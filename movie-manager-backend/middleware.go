@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maximum length of a client-supplied X-Request-ID that is passed through
+const maxRequestIDLength = 128
+
+// requestIDMiddleware tags every request with an ID, kept from the client's X-Request-ID
+// when it is short and printable and generated otherwise, and echoes it in the response
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLength || strings.IndexFunc(id, func(r rune) bool { return r < '!' || r > '~' }) >= 0 {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set("requestID", id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// requestID returns the ID assigned by requestIDMiddleware
+func requestID(c *gin.Context) string {
+	return c.GetString("requestID")
+}
+
+// recoveryMiddleware turns a panicking handler into a JSON 500 carrying the request ID,
+// logging the panic and its stack under the same ID
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				// the handler gave up on the connection on purpose
+				panic(err)
+			}
+			log.Printf("[%s] panic serving %s %s: %v\n%s", requestID(c), c.Request.Method, c.Request.URL.Path, err, debug.Stack())
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "requestId": requestID(c)})
+		}()
+		c.Next()
+	}
+}
+
+// requestLogger logs one line per request through the standard logger, in every Gin mode
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+		path := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			path += "?" + c.Request.URL.RawQuery
+		}
+		log.Printf("[%s] %s %s %d %s %s", requestID(c), c.Request.Method, path, c.Writer.Status(), time.Since(started).Round(time.Microsecond), c.ClientIP())
+	}
+}
+
+// bodyLimitMiddleware caps the request body of mutating requests at limit bytes. A declared
+// Content-Length over the limit is refused up front; otherwise reading past it fails with an
+// *http.MaxBytesError, which handlers report through bodyTooLarge.
+func bodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.Request.ContentLength > limit {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large", "limit": limit})
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
+
+// bodyTooLarge writes a 413 and returns true when err came from reading past the body limit
+func bodyTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large", "limit": tooLarge.Limit})
+	return true
+}
+
+// routes that use POST only to carry a request body and never write
+var readOnlyPostRoutes = []string{"/movies/validate", "/movies/by-ids"}
+
+// isReadOnlyPost reports whether the request matched one of readOnlyPostRoutes
+func isReadOnlyPost(c *gin.Context) bool {
+	if c.Request.Method != http.MethodPost {
+		return false
+	}
+	for _, route := range readOnlyPostRoutes {
+		if strings.HasSuffix(c.FullPath(), route) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyMiddleware rejects every mutating request so the API can be exposed publicly as a demo
+func readOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isReadOnlyPost(c) {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Server is in read-only mode"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminMiddleware admits only requests carrying ADMIN_API_KEY in X-API-Key or as an
+// Authorization bearer token. Admin routes are disabled entirely while the variable is unset.
+func adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := os.Getenv("ADMIN_API_KEY")
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled, set ADMIN_API_KEY to enable them"})
+			return
+		}
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" {
+			provided, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "A valid API key is required"})
+			return
+		}
+		c.Next()
+	}
+}
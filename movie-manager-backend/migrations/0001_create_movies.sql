@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS movies (
+	id SERIAL PRIMARY KEY,
+	title VARCHAR(255) NOT NULL UNIQUE,
+	genre VARCHAR(100),
+	year INT,
+	rating INT CHECK (rating >= 0 AND rating <= 5)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS movie_tombstones (
+	movie_id INT PRIMARY KEY,
+	deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_tombstones_deleted_at_idx ON movie_tombstones (deleted_at);
+CREATE INDEX IF NOT EXISTS movies_updated_at_idx ON movies (updated_at);
+
+CREATE OR REPLACE FUNCTION record_movie_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+	INSERT INTO movie_tombstones (movie_id) VALUES (OLD.id)
+	ON CONFLICT (movie_id) DO UPDATE SET deleted_at = NOW();
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_record_tombstone ON movies;
+CREATE TRIGGER movies_record_tombstone
+	AFTER DELETE ON movies
+	FOR EACH ROW EXECUTE FUNCTION record_movie_tombstone();
@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS runtime_minutes INT CHECK (runtime_minutes >= 0);
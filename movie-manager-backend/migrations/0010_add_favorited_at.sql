@@ -0,0 +1,22 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS favorited_at TIMESTAMPTZ;
+
+-- existing favorites have no recorded time; their last update is the best approximation
+UPDATE movies SET favorited_at = updated_at WHERE favorite AND favorited_at IS NULL;
+
+CREATE OR REPLACE FUNCTION set_favorited_at() RETURNS TRIGGER AS $$
+BEGIN
+	IF NEW.favorite AND (TG_OP = 'INSERT' OR NOT COALESCE(OLD.favorite, false)) THEN
+		NEW.favorited_at = NOW();
+	ELSIF NOT COALESCE(NEW.favorite, false) THEN
+		NEW.favorited_at = NULL;
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_set_favorited_at ON movies;
+CREATE TRIGGER movies_set_favorited_at
+	BEFORE INSERT OR UPDATE ON movies
+	FOR EACH ROW EXECUTE FUNCTION set_favorited_at();
+
+CREATE INDEX IF NOT EXISTS movies_favorited_at_idx ON movies (favorited_at) WHERE favorite;
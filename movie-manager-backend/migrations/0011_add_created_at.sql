@@ -0,0 +1,4 @@
+-- rows that predate this column are stamped with the migration time
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS movies_created_at_idx ON movies (created_at);
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS movie_history (
+	id BIGSERIAL PRIMARY KEY,
+	movie_id INT NOT NULL,
+	action TEXT NOT NULL,
+	changes JSONB NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_history_movie_id_idx ON movie_history (movie_id, id);
+
+-- runs inside the mutating statement's transaction, so every write path is covered.
+-- inserts and deletes store the whole row; updates store only the fields that changed,
+-- ignoring the bookkeeping columns that change on every write.
+CREATE OR REPLACE FUNCTION record_movie_history() RETURNS TRIGGER AS $$
+DECLARE
+	changed JSONB;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (NEW.id, 'create', to_jsonb(NEW));
+		RETURN NEW;
+	ELSIF TG_OP = 'DELETE' THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (OLD.id, 'delete', to_jsonb(OLD));
+		RETURN OLD;
+	END IF;
+
+	SELECT jsonb_object_agg(n.key, n.value) INTO changed
+	FROM jsonb_each(to_jsonb(NEW)) n
+	JOIN jsonb_each(to_jsonb(OLD)) o ON o.key = n.key
+	WHERE n.value IS DISTINCT FROM o.value
+		AND n.key NOT IN ('version', 'updated_at', 'favorited_at');
+
+	IF changed IS NOT NULL THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (NEW.id, 'update', changed);
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_record_history ON movies;
+CREATE TRIGGER movies_record_history
+	AFTER INSERT OR UPDATE OR DELETE ON movies
+	FOR EACH ROW EXECUTE FUNCTION record_movie_history();
@@ -0,0 +1,2 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster_url TEXT;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS description TEXT;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS movie_ratings (
+	movie_id INT NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	value INT NOT NULL CHECK (value >= 0 AND value <= 5),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (movie_id, user_id)
+);
+
+-- denormalized onto movies so every movie read gets the aggregate without a join
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION refresh_movie_rating() RETURNS TRIGGER AS $$
+DECLARE
+	target INT;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		target = OLD.movie_id;
+	ELSE
+		target = NEW.movie_id;
+	END IF;
+	UPDATE movies SET
+		rating_average = (SELECT ROUND(AVG(value), 2) FROM movie_ratings WHERE movie_id = target),
+		rating_count = (SELECT COUNT(*) FROM movie_ratings WHERE movie_id = target)
+	WHERE id = target;
+	-- a rating moved between movies (e.g. by a merge) changes the old movie too
+	IF TG_OP = 'UPDATE' AND OLD.movie_id <> NEW.movie_id THEN
+		UPDATE movies SET
+			rating_average = (SELECT ROUND(AVG(value), 2) FROM movie_ratings WHERE movie_id = OLD.movie_id),
+			rating_count = (SELECT COUNT(*) FROM movie_ratings WHERE movie_id = OLD.movie_id)
+		WHERE id = OLD.movie_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movie_ratings_refresh_movie ON movie_ratings;
+CREATE TRIGGER movie_ratings_refresh_movie
+	AFTER INSERT OR UPDATE OR DELETE ON movie_ratings
+	FOR EACH ROW EXECUTE FUNCTION refresh_movie_rating();
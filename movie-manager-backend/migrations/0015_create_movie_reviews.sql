@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS movie_reviews (
+	id SERIAL PRIMARY KEY,
+	movie_id INT NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	author VARCHAR(100) NOT NULL,
+	body TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_reviews_movie_id_idx ON movie_reviews (movie_id, created_at);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS review_count INT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION refresh_movie_review_count() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE movies SET review_count = review_count + 1 WHERE id = NEW.movie_id;
+	ELSIF TG_OP = 'DELETE' THEN
+		UPDATE movies SET review_count = review_count - 1 WHERE id = OLD.movie_id;
+	ELSIF OLD.movie_id <> NEW.movie_id THEN
+		UPDATE movies SET review_count = review_count - 1 WHERE id = OLD.movie_id;
+		UPDATE movies SET review_count = review_count + 1 WHERE id = NEW.movie_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movie_reviews_refresh_movie ON movie_reviews;
+CREATE TRIGGER movie_reviews_refresh_movie
+	AFTER INSERT OR UPDATE OR DELETE ON movie_reviews
+	FOR EACH ROW EXECUTE FUNCTION refresh_movie_review_count();
+
+-- the rating and review aggregates are derived, not edits, so keep them out of the history
+CREATE OR REPLACE FUNCTION record_movie_history() RETURNS TRIGGER AS $$
+DECLARE
+	changed JSONB;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (NEW.id, 'create', to_jsonb(NEW));
+		RETURN NEW;
+	ELSIF TG_OP = 'DELETE' THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (OLD.id, 'delete', to_jsonb(OLD));
+		RETURN OLD;
+	END IF;
+
+	SELECT jsonb_object_agg(n.key, n.value) INTO changed
+	FROM jsonb_each(to_jsonb(NEW)) n
+	JOIN jsonb_each(to_jsonb(OLD)) o ON o.key = n.key
+	WHERE n.value IS DISTINCT FROM o.value
+		AND n.key NOT IN ('version', 'updated_at', 'favorited_at', 'rating_average', 'rating_count', 'review_count');
+
+	IF changed IS NOT NULL THEN
+		INSERT INTO movie_history (movie_id, action, changes) VALUES (NEW.id, 'update', changed);
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS movie_tags (
+	movie_id INT NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+	PRIMARY KEY (movie_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_tags_tag_id_idx ON movie_tags (tag_id);
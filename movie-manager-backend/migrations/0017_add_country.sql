@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS country VARCHAR(100);
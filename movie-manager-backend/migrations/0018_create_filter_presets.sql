@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS filter_presets (
+	name VARCHAR(50) PRIMARY KEY,
+	params JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
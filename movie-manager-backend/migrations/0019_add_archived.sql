@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT false;
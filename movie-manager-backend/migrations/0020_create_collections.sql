@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS collections (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS collection_id INT REFERENCES collections (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS movies_collection_id_idx ON movies (collection_id);
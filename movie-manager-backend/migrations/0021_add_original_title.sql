@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS original_title VARCHAR(255);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS movie_views (
+	id BIGSERIAL PRIMARY KEY,
+	movie_id INT NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movie_views_viewed_at_idx ON movie_views (viewed_at, movie_id);
@@ -0,0 +1,10 @@
+-- the scale ratings are stored on; changed at startup when RATING_SCALE differs
+CREATE TABLE IF NOT EXISTS rating_scale (
+	id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+	scale INT NOT NULL CHECK (scale > 0)
+);
+
+INSERT INTO rating_scale (scale) VALUES (5) ON CONFLICT DO NOTHING;
+
+-- room for averages on scales up to 100
+ALTER TABLE movies ALTER COLUMN rating_average TYPE NUMERIC(5, 2);
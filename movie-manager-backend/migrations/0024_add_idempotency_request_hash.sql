@@ -0,0 +1,4 @@
+-- a key is reserved before its movie is created, so the response is filled in later
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS request_hash CHAR(64);
+ALTER TABLE idempotency_keys ALTER COLUMN movie_id DROP NOT NULL;
+ALTER TABLE idempotency_keys ALTER COLUMN response DROP NOT NULL;
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond writes obj as XML when the Accept header prefers application/xml, and as JSON
+// otherwise, including when no Accept header is sent
+func respond(c *gin.Context, status int, obj interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2) == gin.MIMEJSON {
+		c.JSON(status, obj)
+		return
+	}
+	c.XML(status, toXML(obj))
+}
+
+// xmlMap encodes a map as one child element per key, in key order. Unlike gin.H it
+// also handles nested maps and slices of maps, which encoding/xml rejects.
+type xmlMap map[string]interface{}
+
+func (m xmlMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if start.Name.Local == "xmlMap" {
+		start.Name.Local = "response"
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := e.EncodeElement(m[key], xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// toXML rewrites the maps inside v as xmlMaps so the whole value can be encoded;
+// structs such as Movie carry their own xml tags and are left alone
+func toXML(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(xmlMap, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = toXML(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = toXML(rv.Index(i).Interface())
+		}
+		return items
+	}
+	return v
+}
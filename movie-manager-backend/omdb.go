@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const omdbBaseURL = "https://www.omdbapi.com/"
+
+var omdbClient = &http.Client{Timeout: 10 * time.Second}
+
+// errOMDbNotFound is returned when OMDb has no match for the requested title
+var errOMDbNotFound = errors.New("movie not found on OMDb")
+
+// raw OMDb response, only the fields we map
+type omdbMovie struct {
+	Title      string `json:"Title"`
+	Year       string `json:"Year"`
+	Genre      string `json:"Genre"`
+	ImdbRating string `json:"imdbRating"`
+	Response   string `json:"Response"`
+	Error      string `json:"Error"`
+}
+
+// request body for importing a movie by title
+type OMDbImportInput struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// fetchOMDbMovie looks up a movie by title on OMDb, through omdbBreaker
+func fetchOMDbMovie(ctx context.Context, title string) (*omdbMovie, error) {
+	apiKey := os.Getenv("OMDB_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("OMDB_API_KEY environment variable is not set")
+	}
+
+	params := url.Values{}
+	params.Set("apikey", apiKey)
+	params.Set("t", title)
+	params.Set("type", "movie")
+
+	var result omdbMovie
+	err := omdbBreaker.call(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, omdbBaseURL+"?"+params.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := omdbClient.Do(req)
+		if err != nil {
+			// drop the URL from the error so the API key never reaches a response
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return &upstreamError{fmt.Errorf("OMDb request failed: %w", err)}
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return &upstreamError{fmt.Errorf("OMDb returned status %d", resp.StatusCode)}
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("OMDb returned status %d", resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode OMDb response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Response != "True" {
+		if result.Error == "Movie not found!" {
+			return nil, errOMDbNotFound
+		}
+		return nil, fmt.Errorf("OMDb error: %s", result.Error)
+	}
+	return &result, nil
+}
+
+// toMovie maps an OMDb result onto our movie model.
+// OMDb reports years like "2010" or "2010–2013", a comma-separated genre list of which
+// the first is kept, and an IMDb rating out of 10, which is rescaled and rounded onto ratingScale()
+// (a missing "N/A" rating leaves the movie unrated).
+func (m *omdbMovie) toMovie() (Movie, error) {
+	primaryGenre, _, _ := strings.Cut(m.Genre, ",")
+	movie := Movie{
+		Title: strings.TrimSpace(m.Title),
+		Genre: strings.TrimSpace(primaryGenre),
+	}
+	if movie.Title == "" || movie.Title == "N/A" {
+		return movie, errors.New("OMDb result is missing a title")
+	}
+	if movie.Genre == "N/A" {
+		movie.Genre = ""
+	}
+
+	if len(m.Year) < 4 {
+		return movie, errors.New("OMDb result is missing a release year")
+	}
+	year, err := strconv.Atoi(m.Year[:4])
+	if err != nil {
+		return movie, fmt.Errorf("OMDb returned an invalid release year %q", m.Year)
+	}
+	movie.Year = &year
+
+	if rating, err := strconv.ParseFloat(m.ImdbRating, 64); err == nil {
+		movie.Rating = intPtr(rescaleRating(rating, 10))
+	}
+	return movie, nil
+}
+
+// importMovieFromOMDb fetches a movie from OMDb by title and stores it
+func importMovieFromOMDb(c *gin.Context) {
+	var input OMDbImportInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
+	result, err := fetchOMDbMovie(ctx, input.Title)
+	if err != nil {
+		if errors.Is(err, errOMDbNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found on OMDb"})
+			return
+		}
+		var open *circuitOpenError
+		if errors.As(err, &open) {
+			c.Header("Retry-After", open.retryAfterSeconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error fetching movie from OMDb: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch movie from OMDb", "details": err.Error()})
+		return
+	}
+
+	movie, err := result.toMovie()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := prepareMovie(&movie); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	applyDefaultRating(&movie)
+
+	exists, err := titleExists(db, movie.Title)
+	if err != nil {
+		log.Printf("Error checking for duplicate title: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+		return
+	}
+
+	if err := insertMovie(db, &movie); err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusConflict, apiError(c, codeTitleTaken))
+			return
+		}
+		log.Printf("Error inserting movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieCreated, movie.ID)
+
+	c.Header("Location", movieLocation(movie.ID))
+	c.JSON(http.StatusCreated, movie)
+}
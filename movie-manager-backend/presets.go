@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// presetParams are the list filters a preset may store, as read by parseMovieFilters
+var presetParams = []string{
+	"search", "searchOriginalTitle", "originalTitle", "genre", "language", "country", "year", "minRuntime", "maxRuntime",
+	"favorite", "watched", "archived", "includeArchived", "hasGenre", "hasYear", "hasRating", "unrated", "collection", "updatedSince",
+}
+
+// preset names are used in query strings, so they are kept to URL-safe characters
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,50}$`)
+
+// a named set of list filters, applied with ?preset=name
+type FilterPreset struct {
+	Name      string            `json:"name" binding:"required"`
+	Params    map[string]string `json:"params" binding:"required"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// validatePreset checks the name and that every param is a known, non-empty filter
+func validatePreset(preset *FilterPreset) error {
+	if !presetNamePattern.MatchString(preset.Name) {
+		return fmt.Errorf("name must be 1-50 letters, digits, hyphens or underscores")
+	}
+	if len(preset.Params) == 0 {
+		return fmt.Errorf("params must contain at least one filter")
+	}
+	for key, value := range preset.Params {
+		known := false
+		for _, param := range presetParams {
+			known = known || key == param
+		}
+		if !known {
+			return fmt.Errorf("unknown filter %q; presets may store %s", key, strings.Join(presetParams, ", "))
+		}
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("filter %q must not be empty", key)
+		}
+	}
+	return nil
+}
+
+// createPreset saves a named filter preset; names are unique
+func createPreset(c *gin.Context) {
+	var preset FilterPreset
+	if !bindJSON(c, &preset) {
+		return
+	}
+	if err := validatePreset(&preset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params, err := json.Marshal(preset.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid params", "details": err.Error()})
+		return
+	}
+	err = db.QueryRow("INSERT INTO filter_presets (name, params) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING RETURNING created_at",
+		preset.Name, params).Scan(&preset.CreatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusConflict, gin.H{"error": "A preset with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving preset: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preset", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, preset)
+}
+
+// getPresets lists every saved preset by name
+func getPresets(c *gin.Context) {
+	rows, err := db.Query("SELECT name, params, created_at FROM filter_presets ORDER BY name")
+	if err != nil {
+		log.Printf("Error fetching presets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch presets", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	presets := []FilterPreset{}
+	for rows.Next() {
+		var preset FilterPreset
+		var params []byte
+		if err := rows.Scan(&preset.Name, &params, &preset.CreatedAt); err != nil {
+			log.Printf("Error scanning preset row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan presets", "details": err.Error()})
+			return
+		}
+		if err := json.Unmarshal(params, &preset.Params); err != nil {
+			log.Printf("Error decoding preset %s: %v", preset.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode presets", "details": err.Error()})
+			return
+		}
+		presets = append(presets, preset)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve presets", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"presets": presets})
+}
+
+// deletePreset removes a saved preset by name
+func deletePreset(c *gin.Context) {
+	result, err := db.Exec("DELETE FROM filter_presets WHERE name = $1", c.Param("name"))
+	if err != nil {
+		log.Printf("Error deleting preset: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete preset", "details": err.Error()})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, apiError(c, codePresetNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preset deleted successfully"})
+}
+
+// presetMiddleware expands ?preset=name into the preset's stored filters before the
+// handler reads the query. Parameters given explicitly in the request take precedence.
+func presetMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		name := query.Get("preset")
+		if name == "" {
+			c.Next()
+			return
+		}
+
+		var raw []byte
+		err := db.QueryRow("SELECT params FROM filter_presets WHERE name = $1", name).Scan(&raw)
+		if err == sql.ErrNoRows {
+			c.AbortWithStatusJSON(http.StatusNotFound, apiError(c, codePresetNotFound, gin.H{"preset": name}))
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching preset: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preset", "details": err.Error()})
+			return
+		}
+		var params map[string]string
+		if err := json.Unmarshal(raw, &params); err != nil {
+			log.Printf("Error decoding preset %s: %v", name, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode preset", "details": err.Error()})
+			return
+		}
+
+		for key, value := range params {
+			if !query.Has(key) {
+				query.Set(key, value)
+			}
+		}
+		c.Request.URL.RawQuery = query.Encode()
+		c.Next()
+	}
+}
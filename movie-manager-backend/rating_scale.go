@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ratings run from 0 to the rating scale; the scale is 5 unless RATING_SCALE says otherwise
+const (
+	defaultRatingScale = 5
+	maxRatingScale     = 100
+)
+
+// activeRatingScale is the scale ratings are stored and validated on, set by applyRatingScale
+var activeRatingScale = defaultRatingScale
+
+// ratingScale returns the highest rating a movie or user may give
+func ratingScale() int {
+	return activeRatingScale
+}
+
+// validateRating checks that a rating is between 0 and the rating scale
+func validateRating(rating int) error {
+	if rating < 0 || rating > ratingScale() {
+		return fmt.Errorf("Rating must be between 0 and %d", ratingScale())
+	}
+	return nil
+}
+
+// rescaleRating maps a rating out of from onto the active scale, rounding to the nearest
+// whole rating. Imports use it for sources such as IMDb (out of 10) or Letterboxd (out of 5).
+func rescaleRating(rating float64, from int) int {
+	return int(math.Round(rating * float64(ratingScale()) / float64(from)))
+}
+
+// configuredRatingScale reads RATING_SCALE, which must be a whole number from 1 to maxRatingScale
+func configuredRatingScale() (int, error) {
+	raw := os.Getenv("RATING_SCALE")
+	if raw == "" {
+		return defaultRatingScale, nil
+	}
+	scale, err := strconv.Atoi(raw)
+	if err != nil || scale < 1 || scale > maxRatingScale {
+		return 0, fmt.Errorf("RATING_SCALE=%q must be a whole number from 1 to %d", raw, maxRatingScale)
+	}
+	return scale, nil
+}
+
+// rating columns bounded by the scale, with the CHECK constraint that enforces it
+var scaledRatingColumns = []struct{ table, column, constraint string }{
+	{"movies", "rating", "movies_rating_check"},
+	{"movie_ratings", "value", "movie_ratings_value_check"},
+}
+
+// applyRatingScale makes RATING_SCALE the active scale. When it differs from the scale the
+// stored ratings use, every rating is converted proportionally (3 of 5 becomes 6 of 10) and
+// the CHECK constraints are moved to the new bound, all in one transaction. Converted movies
+// get a new version so edits made against the old values are rejected.
+func applyRatingScale(db *sql.DB) error {
+	scale, err := configuredRatingScale()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting rating scale check: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stored int
+	if err := tx.QueryRow("SELECT scale FROM rating_scale FOR UPDATE").Scan(&stored); err != nil {
+		return fmt.Errorf("reading stored rating scale: %w", err)
+	}
+	if stored != scale {
+		for _, c := range scaledRatingColumns {
+			if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s", c.table, c.constraint)); err != nil {
+				return fmt.Errorf("dropping %s: %w", c.constraint, err)
+			}
+			// the movie_ratings trigger recomputes each movie's rating_average as its values change
+			rescale := fmt.Sprintf("UPDATE %[1]s SET %[2]s = ROUND(%[2]s * $1::numeric / $2) WHERE %[2]s IS NOT NULL", c.table, c.column)
+			if _, err := tx.Exec(rescale, scale, stored); err != nil {
+				return fmt.Errorf("rescaling %s.%s: %w", c.table, c.column, err)
+			}
+			check := fmt.Sprintf("ALTER TABLE %[1]s ADD CONSTRAINT %[2]s CHECK (%[3]s >= 0 AND %[3]s <= %[4]d)", c.table, c.constraint, c.column, scale)
+			if _, err := tx.Exec(check); err != nil {
+				return fmt.Errorf("adding %s: %w", c.constraint, err)
+			}
+		}
+		if _, err := tx.Exec("UPDATE movies SET version = version + 1 WHERE rating IS NOT NULL OR rating_count > 0"); err != nil {
+			return fmt.Errorf("bumping rescaled movie versions: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE rating_scale SET scale = $1", scale); err != nil {
+			return fmt.Errorf("saving rating scale: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing rating scale: %w", err)
+	}
+
+	if stored != scale {
+		log.Printf("Converted stored ratings from a scale of %d to %d.", stored, scale)
+	}
+	activeRatingScale = scale
+	return nil
+}
+
+// ratingScaleMiddleware advertises the active scale on every response, so clients can render
+// a rating control with the right range
+func ratingScaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Rating-Scale", strconv.Itoa(ratingScale()))
+		c.Next()
+	}
+}
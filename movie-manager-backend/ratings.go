@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maximum accepted length of an X-User-ID header, matching the user_id column
+const maxUserIDLength = 255
+
+// request body for setting the current user's rating
+type UserRatingInput struct {
+	Value *int `json:"value" binding:"required,gte=0"` // at most ratingScale()
+}
+
+// one user's rating of a movie
+type UserRating struct {
+	UserID    string    `json:"userId"`
+	Value     int       `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// requestUserID returns the caller's X-User-ID header, writing a 400 when it is missing or too long.
+// There are no accounts, so the header is trusted as-is to tell raters apart.
+func requestUserID(c *gin.Context) (string, bool) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header is required"})
+		return "", false
+	}
+	if len(userID) > maxUserIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID must be at most 255 characters"})
+		return "", false
+	}
+	return userID, true
+}
+
+// setUserRating records or replaces the current user's rating of a movie and
+// returns the movie's updated aggregate
+func setUserRating(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	var input UserRatingInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateRating(*input.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// selecting from movies means nothing is inserted for an unknown movie
+	result, err := db.Exec(`
+	INSERT INTO movie_ratings (movie_id, user_id, value) SELECT id, $2, $3 FROM movies WHERE id = $1
+	ON CONFLICT (movie_id, user_id) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()`,
+		id, userID, *input.Value)
+	if err != nil {
+		log.Printf("Error saving user rating: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating", "details": err.Error()})
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error getting rows affected: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check rating status", "details": err.Error()})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+		return
+	}
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		log.Printf("Error fetching rated movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            id,
+		"userId":        userID,
+		"value":         *input.Value,
+		"averageRating": movie.AverageRating,
+		"ratingCount":   movie.RatingCount,
+	})
+}
+
+// getMovieRatings returns every user's rating of a movie, newest first, with the aggregate
+func getMovieRatings(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Query("SELECT user_id, value, updated_at FROM movie_ratings WHERE movie_id = $1 ORDER BY updated_at DESC, user_id", id)
+	if err != nil {
+		log.Printf("Error fetching user ratings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	ratings := []UserRating{}
+	for rows.Next() {
+		var r UserRating
+		if err := rows.Scan(&r.UserID, &r.Value, &r.UpdatedAt); err != nil {
+			log.Printf("Error scanning user rating row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read ratings", "details": err.Error()})
+			return
+		}
+		ratings = append(ratings, r)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read ratings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            id,
+		"averageRating": movie.AverageRating,
+		"ratingCount":   movie.RatingCount,
+		"ratings":       ratings,
+	})
+}
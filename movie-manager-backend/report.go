@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// a one-page summary of the catalogue; the pointer fields are nil when the catalogue
+// has nothing to report for them, e.g. no rated movies yet
+type StatsReport struct {
+	Total         int         `json:"total"`
+	AverageRating *float64    `json:"averageRating"`
+	TopGenre      *GenreStats `json:"topGenre"`
+	Oldest        *Movie      `json:"oldest"`
+	Newest        *Movie      `json:"newest"`
+	HighestRated  *Movie      `json:"highestRated"`
+	GeneratedAt   time.Time   `json:"generatedAt"`
+}
+
+// firstMovie returns the first movie matching the WHERE/ORDER BY tail, or nil if there is none
+func firstMovie(tail string) (*Movie, error) {
+	var movie Movie
+	err := scanMovie(db.QueryRow(fmt.Sprintf("SELECT %s FROM movies %s LIMIT 1", movieColumns, tail)), &movie)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// buildStatsReport gathers the report from the same aggregates as the other stats endpoints.
+// The top genre ignores the "Unknown" bucket; the highest-rated film is ranked like GET /movies/top.
+// archived is an archivedCondition.
+func buildStatsReport(archived string) (StatsReport, error) {
+	report := StatsReport{GeneratedAt: time.Now().UTC()}
+	if err := db.QueryRow("SELECT COUNT(*), ROUND(AVG(COALESCE(rating_average, rating))::numeric, 2) FROM movies WHERE "+archived).Scan(&report.Total, &report.AverageRating); err != nil {
+		return report, err
+	}
+
+	genres, err := queryGenreStats(archived)
+	if err != nil {
+		return report, err
+	}
+	for i := range genres {
+		if genres[i].Genre != "Unknown" {
+			report.TopGenre = &genres[i]
+			break
+		}
+	}
+
+	if report.Oldest, err = firstMovie("WHERE year IS NOT NULL AND " + archived + " ORDER BY year, id"); err != nil {
+		return report, err
+	}
+	if report.Newest, err = firstMovie("WHERE year IS NOT NULL AND " + archived + " ORDER BY year DESC, id DESC"); err != nil {
+		return report, err
+	}
+	report.HighestRated, err = firstMovie("WHERE COALESCE(rating_average, rating) IS NOT NULL AND " + archived + " ORDER BY COALESCE(rating_average, rating) DESC, year DESC, id")
+	return report, err
+}
+
+// text renders the report for Accept: text/plain
+func (r StatsReport) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Movie catalogue report (%s)\n\n", r.GeneratedAt.Format(time.RFC1123))
+	fmt.Fprintf(&b, "Total movies:       %d\n", r.Total)
+	if r.AverageRating != nil {
+		fmt.Fprintf(&b, "Average rating:     %.2f\n", *r.AverageRating)
+	} else {
+		fmt.Fprintf(&b, "Average rating:     n/a\n")
+	}
+	if r.TopGenre != nil {
+		fmt.Fprintf(&b, "Top genre:          %s (%d movies)\n", r.TopGenre.Genre, r.TopGenre.Count)
+	} else {
+		fmt.Fprintf(&b, "Top genre:          n/a\n")
+	}
+	movieLine := func(label string, movie *Movie, detail func(*Movie) string) {
+		if movie == nil {
+			fmt.Fprintf(&b, "%-20s n/a\n", label+":")
+			return
+		}
+		fmt.Fprintf(&b, "%-20s %s (%s)\n", label+":", movie.Title, detail(movie))
+	}
+	year := func(m *Movie) string { return fmt.Sprint(*m.Year) }
+	movieLine("Oldest film", r.Oldest, year)
+	movieLine("Newest film", r.Newest, year)
+	movieLine("Highest-rated film", r.HighestRated, func(m *Movie) string { return fmt.Sprintf("%.2f", *m.AverageRating) })
+	return b.String()
+}
+
+// getStatsReport returns the catalogue summary as JSON, or as plain text when the
+// Accept header asks for text/plain
+func getStatsReport(c *gin.Context) {
+	report, err := buildStatsReport(archivedCondition(c, "archived"))
+	if err != nil {
+		log.Printf("Error building stats report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build stats report", "details": err.Error()})
+		return
+	}
+
+	// Vary: Accept is set by the stats cache in front of this handler
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain {
+		c.String(http.StatusOK, report.text())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limits on review fields, in characters; author matches its column
+const (
+	maxReviewAuthorLength = 100
+	maxReviewBodyLength   = 2000
+)
+
+// a user review of a movie
+type Review struct {
+	ID        int       `json:"id"`
+	MovieID   int       `json:"movieId"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// request body for posting a review
+type ReviewInput struct {
+	Author string `json:"author" binding:"required"`
+	Body   string `json:"body" binding:"required"`
+}
+
+// normalize trims both fields and checks they are non-blank and within their limits
+func (r *ReviewInput) normalize() error {
+	r.Author = strings.TrimSpace(r.Author)
+	r.Body = strings.TrimSpace(r.Body)
+	switch {
+	case r.Author == "":
+		return fmt.Errorf("Author must not be blank")
+	case utf8.RuneCountInString(r.Author) > maxReviewAuthorLength:
+		return fmt.Errorf("Author must be at most %d characters", maxReviewAuthorLength)
+	case r.Body == "":
+		return fmt.Errorf("Review body must not be blank")
+	case utf8.RuneCountInString(r.Body) > maxReviewBodyLength:
+		return fmt.Errorf("Review body must be at most %d characters", maxReviewBodyLength)
+	}
+	return nil
+}
+
+// createReview adds a review to a movie
+func createReview(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	var input ReviewInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// selecting from movies means nothing is inserted for an unknown movie
+	review := Review{MovieID: id, Author: input.Author, Body: input.Body}
+	err = db.QueryRow(`
+	INSERT INTO movie_reviews (movie_id, author, body) SELECT id, $2, $3 FROM movies WHERE id = $1
+	RETURNING id, created_at`, id, input.Author, input.Body).Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error inserting review: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// review orderings accepted by GET /movies/:id/reviews?order=
+var reviewOrders = map[string]string{
+	"newest": "created_at DESC, id DESC",
+	"oldest": "created_at ASC, id ASC",
+}
+
+// getMovieReviews lists a movie's reviews, paginated like GET /movies. They are newest
+// first unless order=oldest.
+func getMovieReviews(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+	page, pageSize := pageParams(c, 10)
+	order := c.DefaultQuery("order", "newest")
+	orderBy, ok := reviewOrders[order]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be newest or oldest"})
+		return
+	}
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Query(`
+	SELECT id, movie_id, author, body, created_at FROM movie_reviews
+	WHERE movie_id = $1 ORDER BY `+orderBy+` OFFSET $2 LIMIT $3`,
+		id, (page-1)*pageSize, pageSize)
+	if err != nil {
+		log.Printf("Error fetching reviews: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	reviews := []Review{}
+	for rows.Next() {
+		var r Review
+		if err := rows.Scan(&r.ID, &r.MovieID, &r.Author, &r.Body, &r.CreatedAt); err != nil {
+			log.Printf("Error scanning review row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read reviews", "details": err.Error()})
+			return
+		}
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read reviews", "details": err.Error()})
+		return
+	}
+
+	totalPages := pageCount(movie.ReviewCount, pageSize)
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":    reviews,
+		"total":      movie.ReviewCount,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": totalPages,
+		"hasMore":    page < totalPages,
+		"order":      order,
+	})
+}
+
+// deleteReview removes a single review
+func deleteReview(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidReviewID))
+		return
+	}
+
+	result, err := db.Exec("DELETE FROM movie_reviews WHERE id = $1", id)
+	if err != nil {
+		log.Printf("Error deleting review: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review", "details": err.Error()})
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error getting rows affected: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check delete status", "details": err.Error()})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, apiError(c, codeReviewNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
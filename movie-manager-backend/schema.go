@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// describes one movie field for clients that build their forms from the server's rules.
+// Bounds are omitted when a field has none.
+type FieldSchema struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Nullable  bool     `json:"nullable"`
+	ReadOnly  bool     `json:"readOnly"`
+	Min       *int     `json:"min,omitempty"`
+	Max       *int     `json:"max,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Enum      []string `json:"enum,omitempty"`     // the only accepted values
+	Examples  []string `json:"examples,omitempty"` // known values, others are accepted too
+}
+
+// maxLengths are the length limits the normalize* helpers enforce, by JSON field name
+var maxLengths = map[string]int{
+	"title":         maxTitleLength,
+	"originalTitle": maxTitleLength,
+	"language":      maxLanguageLength,
+	"country":       maxCountryLength,
+}
+
+// movieSchema describes the fields of Movie. Names, types, nullability and required flags come
+// from the struct and its binding tags, writability from movieWriteColumns, and the remaining
+// bounds from the same constants and helpers prepareMovie validates with.
+func movieSchema() []FieldSchema {
+	writable := map[string]bool{}
+	for _, column := range movieWriteColumns {
+		writable[snakeToCamel(column)] = true
+	}
+
+	fields := []FieldSchema{}
+	movieType := reflect.TypeOf(Movie{})
+	for i := 0; i < movieType.NumField(); i++ {
+		sf := movieType.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		field := FieldSchema{Name: name, ReadOnly: !writable[name]}
+		t := sf.Type
+		if t.Kind() == reflect.Pointer {
+			field.Nullable = true
+			t = t.Elem()
+		}
+		switch {
+		case t == reflect.TypeOf(time.Time{}):
+			field.Type = "datetime"
+		case t.Kind() == reflect.String:
+			field.Type = "string"
+		case t.Kind() == reflect.Bool:
+			field.Type = "boolean"
+		case t.Kind() == reflect.Float64:
+			field.Type = "number"
+		default:
+			field.Type = "integer"
+		}
+
+		for _, rule := range strings.Split(sf.Tag.Get("binding"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			bound, err := strconv.Atoi(value)
+			switch {
+			case key == "required":
+				field.Required = true
+			case key == "gte" && err == nil:
+				field.Min = intPtr(bound)
+			case key == "lte" && err == nil:
+				field.Max = intPtr(bound)
+			}
+		}
+		if maxLength, ok := maxLengths[name]; ok {
+			field.MaxLength = intPtr(maxLength)
+		}
+		switch name {
+		case "year":
+			field.Min, field.Max = intPtr(minYear), intPtr(currentYear())
+		case "rating":
+			field.Max = intPtr(ratingScale())
+		case "genre":
+			if strictGenres() {
+				field.Enum = allowedGenres()
+			} else {
+				field.Examples = allowedGenres()
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// snakeToCamel turns a column name such as runtime_minutes into its JSON key, runtimeMinutes
+func snakeToCamel(column string) string {
+	parts := strings.Split(column, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// getMovieSchema returns movieSchema for form builders
+func getMovieSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"fields": movieSchema(), "ratingScale": ratingScale()})
+}
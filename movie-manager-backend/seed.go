@@ -0,0 +1,41 @@
+package main
+
+import "log"
+
+// demo movies inserted by the -seed flag
+var demoMovies = []Movie{
+	{Title: "The Shawshank Redemption", Genre: "Drama", Year: intPtr(1994), Rating: intPtr(5)},
+	{Title: "The Godfather", Genre: "Crime", Year: intPtr(1972), Rating: intPtr(5)},
+	{Title: "The Dark Knight", Genre: "Action", Year: intPtr(2008), Rating: intPtr(5)},
+	{Title: "Pulp Fiction", Genre: "Crime", Year: intPtr(1994), Rating: intPtr(4)},
+	{Title: "Inception", Genre: "Sci-Fi", Year: intPtr(2010), Rating: intPtr(4)},
+	{Title: "Spirited Away", Genre: "Animation", Year: intPtr(2001), Rating: intPtr(5)},
+	{Title: "Parasite", Genre: "Thriller", Year: intPtr(2019), Rating: intPtr(4)},
+	{Title: "Casablanca", Genre: "Romance", Year: intPtr(1942), Rating: intPtr(4)},
+	{Title: "The Matrix", Genre: "Sci-Fi", Year: intPtr(1999), Rating: intPtr(4)},
+	{Title: "Toy Story", Genre: "Animation", Year: intPtr(1995), Rating: intPtr(4)},
+	{Title: "Jurassic Park", Genre: "Adventure", Year: intPtr(1993), Rating: intPtr(3)},
+	{Title: "The Grand Budapest Hotel", Genre: "Comedy", Year: intPtr(2014), Rating: intPtr(4)},
+}
+
+// seedDemoData inserts the demo movies, skipping titles that already exist
+func seedDemoData() {
+	inserted := 0
+	for _, demo := range demoMovies {
+		movie := demo
+		exists, err := titleExists(db, movie.Title)
+		if err != nil {
+			log.Printf("Error checking for duplicate title %q while seeding: %v", movie.Title, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+		if err := insertMovie(db, &movie); err != nil {
+			log.Printf("Error inserting demo movie %q: %v", movie.Title, err)
+			continue
+		}
+		inserted++
+	}
+	log.Printf("Seeded %d demo movies (%d already present).", inserted, len(demoMovies)-inserted)
+}
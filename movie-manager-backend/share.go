@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// side length in pixels of the QR code PNG returned by GET /movies/:id/share?qr=true
+const shareQRSize = 256
+
+// shareToken is the short token for a movie: its ID in base 36
+func shareToken(id int) string {
+	return strconv.FormatInt(int64(id), 36)
+}
+
+// absoluteURL prefixes path with the scheme (see requestScheme) and host the request was made to
+func absoluteURL(c *gin.Context, path string) string {
+	return requestScheme(c) + "://" + c.Request.Host + path
+}
+
+// shareURL is the link a shared movie opens: SHARE_BASE_URL + "/movies/<id>" when the
+// frontend's address is configured, otherwise the movie's API URL
+func shareURL(c *gin.Context, id int) string {
+	if base := strings.TrimRight(os.Getenv("SHARE_BASE_URL"), "/"); base != "" {
+		return fmt.Sprintf("%s/movies/%d", base, id)
+	}
+	return absoluteURL(c, movieLocation(id))
+}
+
+// getMovieShare returns a compact summary of a movie for sharing, with its link and short
+// link. Pass qr=true to also get a PNG QR code of the link as a data URI.
+func getMovieShare(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+	withQR, _ := strconv.ParseBool(c.Query("qr"))
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	token := shareToken(movie.ID)
+	link := shareURL(c, movie.ID)
+	share := gin.H{
+		"id":       movie.ID,
+		"title":    movie.Title,
+		"year":     movie.Year,
+		"genre":    movie.Genre,
+		"rating":   movie.AverageRating,
+		"url":      link,
+		"token":    token,
+		"shortUrl": absoluteURL(c, fmt.Sprintf("%s/%s/s/%s", apiBasePath(), apiVersion, token)),
+	}
+	if withQR {
+		png, err := qrcode.Encode(link, qrcode.Medium, shareQRSize)
+		if err != nil {
+			log.Printf("Error generating QR code: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code", "details": err.Error()})
+			return
+		}
+		share["qrCode"] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+// resolveShareToken redirects a short link to the movie's share URL
+func resolveShareToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("token"), 36, 32)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown share link"})
+		return
+	}
+
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM movies WHERE id = $1)", id).Scan(&exists); err != nil {
+		log.Printf("Error resolving share link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve share link", "details": err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+		return
+	}
+
+	c.Redirect(http.StatusFound, shareURL(c, int(id)))
+}
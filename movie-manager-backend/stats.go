@@ -0,0 +1,311 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// aggregate figures for one genre; nullable aggregates are nil when no row has a value.
+// Averages use each movie's averageRating, the value GET /movies/top ranks by.
+type GenreStats struct {
+	Genre     string   `json:"genre"`
+	Count     int      `json:"count"`
+	AvgRating *float64 `json:"avgRating"`
+	MinYear   *int     `json:"minYear"`
+	MaxYear   *int     `json:"maxYear"`
+}
+
+// queryGenreStats reports count, average rating and year range per genre, most populous first.
+// Movies with no genre are grouped under "Unknown". archived is an archivedCondition.
+func queryGenreStats(archived string) ([]GenreStats, error) {
+	rows, err := db.Query(`
+	SELECT COALESCE(NULLIF(TRIM(genre), ''), 'Unknown') AS genre_bucket,
+		COUNT(*), ROUND(AVG(COALESCE(rating_average, rating))::numeric, 2), MIN(year), MAX(year)
+	FROM movies
+	WHERE ` + archived + `
+	GROUP BY genre_bucket
+	ORDER BY COUNT(*) DESC, genre_bucket`)
+	if err != nil {
+		return nil, err
+	}
+	return scanGenreStats(rows)
+}
+
+// queryGenreStatsFor reports the same figures as queryGenreStats for just the named genres,
+// in the order given. Names match case-insensitively, and a genre with no movies is
+// reported with a zero count rather than left out.
+func queryGenreStatsFor(genres []string, archived string) ([]GenreStats, error) {
+	rows, err := db.Query(`
+	SELECT g.name, COUNT(m.id), ROUND(AVG(COALESCE(m.rating_average, m.rating))::numeric, 2), MIN(m.year), MAX(m.year)
+	FROM UNNEST($1::text[]) WITH ORDINALITY AS g(name, position)
+	LEFT JOIN movies m ON LOWER(COALESCE(NULLIF(TRIM(m.genre), ''), 'Unknown')) = LOWER(g.name) AND `+archived+`
+	GROUP BY g.name, g.position
+	ORDER BY g.position`, pq.Array(genres))
+	if err != nil {
+		return nil, err
+	}
+	return scanGenreStats(rows)
+}
+
+// scanGenreStats reads and closes rows selecting genre, count, average rating, min and max year
+func scanGenreStats(rows *sql.Rows) ([]GenreStats, error) {
+	defer rows.Close()
+
+	stats := []GenreStats{}
+	for rows.Next() {
+		var s GenreStats
+		if err := rows.Scan(&s.Genre, &s.Count, &s.AvgRating, &s.MinYear, &s.MaxYear); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// most genres GET /movies/stats/genres?genres= may ask for at once
+const maxStatsGenres = 50
+
+// parseGenreList splits a comma-separated genre list, dropping blanks and repeats (ignoring case)
+func parseGenreList(raw string) []string {
+	genres := []string{}
+	seen := map[string]bool{}
+	for _, genre := range strings.Split(raw, ",") {
+		genre = strings.TrimSpace(genre)
+		if genre == "" || seen[strings.ToLower(genre)] {
+			continue
+		}
+		seen[strings.ToLower(genre)] = true
+		genres = append(genres, genre)
+	}
+	return genres
+}
+
+// getGenreStats serves queryGenreStats, or queryGenreStatsFor when genres=Action,Drama
+// narrows it to a comparison of the listed genres
+func getGenreStats(c *gin.Context) {
+	var stats []GenreStats
+	var err error
+	if raw, ok := c.GetQuery("genres"); ok {
+		genres := parseGenreList(raw)
+		if len(genres) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "genres must name at least one genre"})
+			return
+		}
+		if len(genres) > maxStatsGenres {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("genres may name at most %d genres", maxStatsGenres)})
+			return
+		}
+		stats, err = queryGenreStatsFor(genres, archivedCondition(c, "m.archived"))
+	} else {
+		stats, err = queryGenreStats(archivedCondition(c, "archived"))
+	}
+	if err != nil {
+		log.Printf("Error fetching genre stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch genre stats", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"genres": stats})
+}
+
+// number of movies at a single rating value
+type RatingBucket struct {
+	Rating int `json:"rating"`
+	Count  int `json:"count"`
+}
+
+// getRatingStats returns how many movies sit at each rating from 0 to ratingScale(), including empty buckets,
+// plus how many are not yet rated. An optional genre filter restricts the histogram to one category.
+func getRatingStats(c *gin.Context) {
+	joinSQL := "LEFT JOIN movies m ON m.rating = r.rating AND " + archivedCondition(c, "m.archived")
+	unratedSQL := "SELECT COUNT(*) FROM movies WHERE rating IS NULL AND " + archivedCondition(c, "archived")
+	args := []interface{}{}
+	if genreFilter := c.Query("genre"); genreFilter != "" {
+		joinSQL += ` AND m.genre ILIKE $1 ESCAPE '\'`
+		unratedSQL += ` AND genre ILIKE $1 ESCAPE '\'`
+		args = append(args, containsPattern(genreFilter))
+	}
+
+	rows, err := db.Query(`
+	SELECT r.rating, COUNT(m.id)
+	FROM generate_series(0, `+strconv.Itoa(ratingScale())+`) AS r(rating) `+joinSQL+`
+	GROUP BY r.rating
+	ORDER BY r.rating`, args...)
+	if err != nil {
+		log.Printf("Error fetching rating stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rating stats", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	buckets := []RatingBucket{}
+	for rows.Next() {
+		var b RatingBucket
+		if err := rows.Scan(&b.Rating, &b.Count); err != nil {
+			log.Printf("Error scanning rating stats row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan rating stats", "details": err.Error()})
+			return
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve rating stats", "details": err.Error()})
+		return
+	}
+
+	var unrated int
+	if err := db.QueryRow(unratedSQL, args...).Scan(&unrated); err != nil {
+		log.Printf("Error counting unrated movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rating stats", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ratings": buckets, "unrated": unrated, "ratingScale": ratingScale()})
+}
+
+// count and average rating for one decade, e.g. 1990 for 1990-1999
+type DecadeStats struct {
+	Decade    int      `json:"decade"`
+	Count     int      `json:"count"`
+	AvgRating *float64 `json:"avgRating"`
+}
+
+// getDecadeStats buckets movies by decade (floor(year/10)*10) in chronological order.
+// Only decades present in the data are returned.
+func getDecadeStats(c *gin.Context) {
+	rows, err := db.Query(`
+	SELECT (FLOOR(year / 10.0) * 10)::int AS decade, COUNT(*), ROUND(AVG(COALESCE(rating_average, rating))::numeric, 2)
+	FROM movies
+	WHERE year IS NOT NULL AND ` + archivedCondition(c, "archived") + `
+	GROUP BY decade
+	ORDER BY decade`)
+	if err != nil {
+		log.Printf("Error fetching decade stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch decade stats", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	stats := []DecadeStats{}
+	for rows.Next() {
+		var s DecadeStats
+		if err := rows.Scan(&s.Decade, &s.Count, &s.AvgRating); err != nil {
+			log.Printf("Error scanning decade stats row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan decade stats", "details": err.Error()})
+			return
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve decade stats", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"decades": stats})
+}
+
+// number of movies added in one period of the timeline
+type TimelineBucket struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+// timelineGranularities maps the granularity parameter to its period label layout
+var timelineGranularities = map[string]string{
+	"day":   time.DateOnly,
+	"week":  time.DateOnly,
+	"month": "2006-01",
+}
+
+// parseTimelineBound reads a from/to parameter given as a date or an RFC3339 timestamp.
+// A bare date used as the upper bound covers that whole day.
+func parseTimelineBound(raw string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// getTimelineStats counts movies by the period they were added in (granularity=day, week
+// or month, default month), oldest first. Periods between the first and last addition that
+// saw none are reported with a zero count. from and to narrow the range of created_at;
+// periods are computed in UTC, and weeks start on Monday.
+func getTimelineStats(c *gin.Context) {
+	granularity := c.DefaultQuery("granularity", "month")
+	layout, ok := timelineGranularities[granularity]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be one of day, week or month"})
+		return
+	}
+
+	clauses := []string{archivedCondition(c, "archived")}
+	args := []interface{}{granularity, "1 " + granularity}
+	for _, bound := range []struct {
+		param string
+		op    string
+	}{{"from", ">="}, {"to", "<"}} {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		t, err := parseTimelineBound(raw, bound.param == "to")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bound.param + " must be a date (YYYY-MM-DD) or an RFC3339 timestamp"})
+			return
+		}
+		args = append(args, t)
+		clauses = append(clauses, fmt.Sprintf("created_at %s $%d", bound.op, len(args)))
+	}
+	whereSQL := "WHERE " + strings.Join(clauses, " AND ")
+
+	rows, err := db.Query(`
+	WITH counts AS (
+		SELECT date_trunc($1, created_at AT TIME ZONE 'UTC') AS period, COUNT(*) AS n
+		FROM movies `+whereSQL+`
+		GROUP BY period
+	)
+	SELECT s.period, COALESCE(counts.n, 0)
+	FROM (SELECT MIN(period) AS first, MAX(period) AS last FROM counts) bounds
+	CROSS JOIN generate_series(bounds.first, bounds.last, $2::interval) AS s(period)
+	LEFT JOIN counts ON counts.period = s.period
+	ORDER BY s.period`, args...)
+	if err != nil {
+		log.Printf("Error fetching timeline stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timeline stats", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	timeline := []TimelineBucket{}
+	for rows.Next() {
+		var period time.Time
+		var b TimelineBucket
+		if err := rows.Scan(&period, &b.Count); err != nil {
+			log.Printf("Error scanning timeline stats row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan timeline stats", "details": err.Error()})
+			return
+		}
+		b.Period = period.Format(layout)
+		timeline = append(timeline, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve timeline stats", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"granularity": granularity, "timeline": timeline})
+}
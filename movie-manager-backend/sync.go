@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// a deleted movie, reported so sync clients can drop it from their cache
+type MovieTombstone struct {
+	ID        int       `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// syncOverlap is how far syncedAt is set back from the database time. updated_at and
+// deleted_at come from NOW(), the start of the writing transaction, so a transaction that
+// began before a sync but committed after it writes a timestamp older than that sync's
+// syncedAt. Setting syncedAt back by syncOverlap picks those writes up on the next call
+// unless the transaction ran for longer than syncOverlap.
+const syncOverlap = time.Minute
+
+// getMovieChanges returns everything that changed since the given RFC3339 timestamp:
+// movies created or updated ("upserts") and movies deleted ("deletions").
+// Clients should pass the returned syncedAt as since on their next call. Consecutive
+// responses overlap by syncOverlap, so clients must apply them idempotently, replacing
+// upserts by id and ignoring deletions of movies they no longer have.
+func getMovieChanges(c *gin.Context) {
+	sinceStr := c.Query("since")
+	if sinceStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since query parameter is required"})
+		return
+	}
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC3339 timestamp"})
+		return
+	}
+
+	// taken from the database before reading, then set back by syncOverlap
+	var syncedAt time.Time
+	if err := db.QueryRow("SELECT NOW() - make_interval(secs => $1)", syncOverlap.Seconds()).Scan(&syncedAt); err != nil {
+		log.Printf("Error reading database time: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes", "details": err.Error()})
+		return
+	}
+
+	upserts, err := queryMovies(fmt.Sprintf("SELECT %s FROM movies WHERE updated_at > $1 ORDER BY updated_at, id", movieColumns), since)
+	if err != nil {
+		log.Printf("Error fetching changed movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Query("SELECT movie_id, deleted_at FROM movie_tombstones WHERE deleted_at > $1 ORDER BY deleted_at, movie_id", since)
+	if err != nil {
+		log.Printf("Error fetching deleted movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	deletions := []MovieTombstone{}
+	for rows.Next() {
+		var t MovieTombstone
+		if err := rows.Scan(&t.ID, &t.DeletedAt); err != nil {
+			log.Printf("Error scanning tombstone row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan changes", "details": err.Error()})
+			return
+		}
+		deletions = append(deletions, t)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve changes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"upserts": upserts, "deletions": deletions, "syncedAt": syncedAt})
+}
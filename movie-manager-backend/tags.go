@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maximum length of a tag name, matching the tags.name column
+const maxTagLength = 50
+
+// tags are stored lowercased and may only use letters, digits, hyphens and underscores
+var tagPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// normalizeTag lowercases and trims a tag name, rejecting empty, overlong or malformed names
+func normalizeTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case tag == "":
+		return "", fmt.Errorf("tag must not be empty")
+	case len(tag) > maxTagLength:
+		return "", fmt.Errorf("tag must be at most %d characters", maxTagLength)
+	case !tagPattern.MatchString(tag):
+		return "", fmt.Errorf("tag may only contain letters, digits, hyphens and underscores")
+	}
+	return tag, nil
+}
+
+// tagMovies attaches a tag to every listed movie in one transaction, creating the tag if needed.
+// Movies that already had the tag are skipped, and IDs that don't exist are reported back.
+func tagMovies(c *gin.Context) {
+	tag, err := normalizeTag(c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var input BatchIDsInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := validateBatchIDs(input.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting tag transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO tags (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", tag); err != nil {
+		log.Printf("Error creating tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+	var tagID int
+	if err := tx.QueryRow("SELECT id FROM tags WHERE name = $1", tag).Scan(&tagID); err != nil {
+		log.Printf("Error fetching tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+
+	// lock the movies so none is deleted before its association is written
+	rows, err := tx.Query("SELECT id FROM movies WHERE id = ANY($1) FOR SHARE", pq.Array(input.IDs))
+	if err != nil {
+		log.Printf("Error fetching movies to tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+	found := map[int]bool{}
+	existing := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning movie ID: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+			return
+		}
+		found[id] = true
+		existing = append(existing, id)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+
+	result, err := tx.Exec(`INSERT INTO movie_tags (movie_id, tag_id) SELECT UNNEST($1::int[]), $2
+		ON CONFLICT DO NOTHING`, pq.Array(existing), tagID)
+	if err != nil {
+		log.Printf("Error tagging movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+	added, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing tags: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag movies", "details": err.Error()})
+		return
+	}
+
+	notFound := []int{}
+	seen := map[int]bool{}
+	for _, id := range input.IDs {
+		if !found[id] && !seen[id] {
+			notFound = append(notFound, id)
+		}
+		seen[id] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag, "added": added, "notFound": notFound})
+}
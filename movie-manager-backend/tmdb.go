@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tmdbBaseURL   = "https://api.themoviedb.org/3"
+	tmdbPosterURL = "https://image.tmdb.org/t/p/w500"
+)
+
+var tmdbClient = &http.Client{Timeout: 10 * time.Second}
+
+// maximum number of candidates returned when no single TMDb result is a confident match
+const maxTMDbCandidates = 5
+
+// tmdbRateLimitError is returned when TMDb answers 429; retryAfter carries its Retry-After header
+type tmdbRateLimitError struct {
+	retryAfter string
+}
+
+func (e *tmdbRateLimitError) Error() string {
+	return "TMDb rate limit exceeded"
+}
+
+// a TMDb search result, only the fields we use
+type tmdbSearchResult struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date"`
+	Overview    string `json:"overview"`
+	PosterPath  string `json:"poster_path"`
+}
+
+// a TMDb movie details response, only the fields we map
+type tmdbMovieDetails struct {
+	tmdbSearchResult
+	Genres []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+}
+
+// a possible match reported back to the client when the search is ambiguous
+type TMDbCandidate struct {
+	TMDbID    int    `json:"tmdbId"`
+	Title     string `json:"title"`
+	Year      int    `json:"year,omitempty"`
+	PosterURL string `json:"posterUrl,omitempty"`
+}
+
+// year returns the release year of a result, or 0 when TMDb has no release date
+func (r *tmdbSearchResult) year() int {
+	if len(r.ReleaseDate) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(r.ReleaseDate[:4])
+	return year
+}
+
+func (r *tmdbSearchResult) posterURL() string {
+	if r.PosterPath == "" {
+		return ""
+	}
+	return tmdbPosterURL + r.PosterPath
+}
+
+// tmdbGet calls a TMDb API path through tmdbBreaker and decodes the JSON response into out
+func tmdbGet(ctx context.Context, path string, params url.Values, out interface{}) error {
+	apiKey := os.Getenv("TMDB_API_KEY")
+	if apiKey == "" {
+		return errors.New("TMDB_API_KEY environment variable is not set")
+	}
+	params.Set("api_key", apiKey)
+
+	return tmdbBreaker.call(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tmdbBaseURL+path+"?"+params.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := tmdbClient.Do(req)
+		if err != nil {
+			// drop the URL from the error so the API key never reaches a response
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return &upstreamError{fmt.Errorf("TMDb request failed: %w", err)}
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return &tmdbRateLimitError{retryAfter: resp.Header.Get("Retry-After")}
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return &upstreamError{fmt.Errorf("TMDb returned status %d", resp.StatusCode)}
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("TMDb returned status %d", resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode TMDb response: %w", err)
+		}
+		return nil
+	})
+}
+
+// searchTMDb looks up movies by title, narrowed to the release year when known
+func searchTMDb(ctx context.Context, title string, year int) ([]tmdbSearchResult, error) {
+	params := url.Values{}
+	params.Set("query", title)
+	if year > 0 {
+		params.Set("primary_release_year", strconv.Itoa(year))
+	}
+	var response struct {
+		Results []tmdbSearchResult `json:"results"`
+	}
+	if err := tmdbGet(ctx, "/search/movie", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// bestTMDbMatch picks the single result whose title and year both match the movie,
+// or just the title when the movie's year is unknown.
+// It returns false when there is no such result, or more than one.
+func bestTMDbMatch(movie Movie, results []tmdbSearchResult) (tmdbSearchResult, bool) {
+	var match tmdbSearchResult
+	matches := 0
+	for _, r := range results {
+		if strings.EqualFold(strings.TrimSpace(r.Title), movie.Title) && (movie.Year == nil || r.year() == *movie.Year) {
+			match = r
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// syncMovieFromTMDb fills in a movie's poster, description and genre from TMDb.
+// The movie is matched by title and year; when that isn't conclusive the candidates are
+// returned with a 409 and nothing is changed. Pass tmdbId to pick a candidate explicitly.
+func syncMovieFromTMDb(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	movie, err := fetchMovie(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error fetching movie: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie", "details": err.Error()})
+		return
+	}
+
+	// the search and the details lookup share one deadline
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
+
+	tmdbID := 0
+	if raw := c.Query("tmdbId"); raw != "" {
+		tmdbID, err = strconv.Atoi(raw)
+		if err != nil || tmdbID < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tmdbId must be a positive integer"})
+			return
+		}
+	} else {
+		year := 0
+		if movie.Year != nil {
+			year = *movie.Year
+		}
+		results, err := searchTMDb(ctx, movie.Title, year)
+		if err != nil {
+			respondTMDbError(c, err)
+			return
+		}
+		if len(results) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No match found on TMDb"})
+			return
+		}
+		match, ok := bestTMDbMatch(movie, results)
+		if !ok {
+			candidates := []TMDbCandidate{}
+			for i := 0; i < len(results) && i < maxTMDbCandidates; i++ {
+				r := results[i]
+				candidates = append(candidates, TMDbCandidate{TMDbID: r.ID, Title: r.Title, Year: r.year(), PosterURL: r.posterURL()})
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "No confident TMDb match, retry with tmdbId", "candidates": candidates})
+			return
+		}
+		tmdbID = match.ID
+	}
+
+	var details tmdbMovieDetails
+	if err := tmdbGet(ctx, "/movie/"+strconv.Itoa(tmdbID), url.Values{}, &details); err != nil {
+		respondTMDbError(c, err)
+		return
+	}
+
+	genre := movie.Genre
+	if len(details.Genres) > 0 {
+		// a TMDb genre outside our list (in strict mode) leaves the stored one alone
+		if normalized, err := normalizeGenre(details.Genres[0].Name); err == nil {
+			genre = normalized
+		}
+	}
+
+	err = scanMovie(db.QueryRow(fmt.Sprintf(
+		"UPDATE movies SET poster_url = $1, description = $2, genre = $3, version = version + 1 WHERE id = $4 RETURNING %s", movieColumns),
+		details.posterURL(), details.Overview, genre, id), &movie)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+			return
+		}
+		log.Printf("Error saving TMDb metadata: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie", "details": err.Error()})
+		return
+	}
+	movieEvents.publish(movieUpdated, id)
+
+	c.JSON(http.StatusOK, movie)
+}
+
+// respondTMDbError maps a TMDb failure onto the response, passing rate limits and an
+// open circuit breaker through as 503s
+func respondTMDbError(c *gin.Context, err error) {
+	var open *circuitOpenError
+	if errors.As(err, &open) {
+		c.Header("Retry-After", open.retryAfterSeconds())
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	var rateLimited *tmdbRateLimitError
+	if errors.As(err, &rateLimited) {
+		if rateLimited.retryAfter != "" {
+			c.Header("Retry-After", rateLimited.retryAfter)
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "TMDb rate limit exceeded, try again later"})
+		return
+	}
+	log.Printf("Error fetching movie from TMDb: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch movie from TMDb", "details": err.Error()})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// default and maximum trending window in days
+const (
+	defaultTrendingDays = 7
+	maxTrendingDays     = 365
+)
+
+// a movie in the trending list with its view count over the window
+type TrendingMovie struct {
+	Movie
+	Views int `json:"views"`
+}
+
+// recordView logs one view of a movie with the current time
+func recordView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+		return
+	}
+
+	var viewedAt time.Time
+	err = db.QueryRow("INSERT INTO movie_views (movie_id) SELECT id FROM movies WHERE id = $1 RETURNING viewed_at", id).Scan(&viewedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, apiError(c, codeMovieNotFound))
+		return
+	}
+	if err != nil {
+		log.Printf("Error recording view: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record view", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id, "viewedAt": viewedAt})
+}
+
+// getTrendingMovies ranks movies by views over the last days days (default 7, at most 365),
+// most viewed first. Movies with no views in the window, and archived ones, are left out,
+// so a quiet window gives an empty list.
+func getTrendingMovies(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultTrendingDays)))
+	if err != nil || days < 1 || days > maxTrendingDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be a whole number from 1 to %d", maxTrendingDays)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	rows, err := db.Query(`SELECT v.movie_id, COUNT(*) AS views
+	FROM movie_views v JOIN movies m ON m.id = v.movie_id
+	WHERE v.viewed_at > NOW() - make_interval(days => $1) AND NOT m.archived
+	GROUP BY v.movie_id
+	ORDER BY views DESC, v.movie_id
+	LIMIT $2`, days, limit)
+	if err != nil {
+		log.Printf("Error fetching trending movies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending movies", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	views := map[int]int{}
+	for rows.Next() {
+		var id, count int
+		if err := rows.Scan(&id, &count); err != nil {
+			log.Printf("Error scanning trending row: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan trending movies", "details": err.Error()})
+			return
+		}
+		ids = append(ids, id)
+		views[id] = count
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error after iterating rows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trending movies", "details": err.Error()})
+		return
+	}
+
+	trending := []TrendingMovie{}
+	if len(ids) > 0 {
+		movies, _, err := fetchMoviesInOrder(ids)
+		if err != nil {
+			log.Printf("Error fetching trending movies: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending movies", "details": err.Error()})
+			return
+		}
+		for _, movie := range movies {
+			trending = append(trending, TrendingMovie{Movie: movie, Views: views[movie.ID]})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"movies": trending, "days": days, "limit": limit})
+}
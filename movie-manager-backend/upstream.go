@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamError marks an external API failure worth retrying and counting against the
+// circuit breaker: a network error, timeout or 5xx. Answers such as "not found" or a
+// rate limit are left unwrapped, since repeating them won't help.
+type upstreamError struct {
+	err error
+}
+
+func (e *upstreamError) Error() string { return e.err.Error() }
+func (e *upstreamError) Unwrap() error { return e.err }
+
+// circuitOpenError is returned without calling the service while its breaker is open
+type circuitOpenError struct {
+	service    string
+	retryAfter time.Duration
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable, try again in %s seconds", e.service, e.retryAfterSeconds())
+}
+
+// retryAfterSeconds is the wait to advertise in a Retry-After header, at least one second
+func (e *circuitOpenError) retryAfterSeconds() string {
+	return fmt.Sprint(int(math.Max(1, math.Ceil(e.retryAfter.Seconds()))))
+}
+
+// circuitBreaker guards calls to one external service. After threshold consecutive failed
+// calls it opens and rejects calls outright; once cooldown has passed it lets a single trial
+// call through (half-open), closing again if that succeeds and reopening if it fails.
+// Each call is itself retried with exponential backoff before it counts as failed.
+//
+// Settings come from EXTERNAL_RETRY_ATTEMPTS (default 3), EXTERNAL_RETRY_BACKOFF (200ms),
+// EXTERNAL_BREAKER_THRESHOLD (5) and EXTERNAL_BREAKER_COOLDOWN (30s), read on first use.
+type circuitBreaker struct {
+	service string
+
+	once      sync.Once
+	attempts  int
+	backoff   time.Duration
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open trial call is in flight
+}
+
+var (
+	omdbBreaker = &circuitBreaker{service: "OMDb"}
+	tmdbBreaker = &circuitBreaker{service: "TMDb"}
+)
+
+func (b *circuitBreaker) loadSettings() {
+	b.once.Do(func() {
+		b.attempts = envInt("EXTERNAL_RETRY_ATTEMPTS", 3)
+		b.backoff = envDuration("EXTERNAL_RETRY_BACKOFF", 200*time.Millisecond)
+		b.threshold = envInt("EXTERNAL_BREAKER_THRESHOLD", 5)
+		b.cooldown = envDuration("EXTERNAL_BREAKER_COOLDOWN", 30*time.Second)
+	})
+}
+
+// allow reports whether a call may go out now
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		return &circuitOpenError{service: b.service, retryAfter: wait}
+	}
+	if b.probing {
+		return &circuitOpenError{service: b.service, retryAfter: b.cooldown}
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a call
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	var failed *upstreamError
+	if !errors.As(err, &failed) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// call runs fn through the breaker, retrying upstreamErrors with exponential backoff.
+// Retries stop once ctx is done, so ctx bounds the whole sequence; fn should make its
+// request with ctx too.
+func (b *circuitBreaker) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	b.loadSettings()
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	var err error
+retry:
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		var failed *upstreamError
+		if !errors.As(err, &failed) || attempt >= b.attempts {
+			break
+		}
+		wait := time.NewTimer(b.backoff * time.Duration(1<<(attempt-1)))
+		select {
+		case <-wait.C:
+		case <-ctx.Done():
+			wait.Stop()
+			break retry
+		}
+	}
+
+	// a caller that went away says nothing about the service, so it isn't counted
+	// either way; its deadline running out is, since that means the service was slow
+	if errors.Is(ctx.Err(), context.Canceled) {
+		b.abandon()
+	} else {
+		b.record(err)
+	}
+	return err
+}
+
+// abandon ends a call without recording an outcome, freeing the half-open trial slot
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// upstreamContext bounds every external call a request makes, retries and backoff included,
+// to two thirds of the server's WriteTimeout, so a slow service ends in a 502 or 503 rather
+// than a dropped connection
+func upstreamContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), serverWriteTimeout()*2/3)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCircuitBreakerIgnoresCanceledCallers(t *testing.T) {
+	b := &circuitBreaker{service: "test"}
+	failing := func(ctx context.Context) error { return &upstreamError{errors.New("connection reset")} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 10; i++ {
+		b.call(ctx, failing)
+	}
+	if b.failures != 0 {
+		t.Fatalf("failures after canceled calls = %d, want 0", b.failures)
+	}
+
+	b.call(context.Background(), failing)
+	if b.failures != 1 {
+		t.Fatalf("failures after a real failure = %d, want 1", b.failures)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// report validation errors under the JSON field names clients actually send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON binds the request body into obj, writing a 400 and returning false on failure.
+// Validation failures are reported per field, e.g. {"errors":{"title":"required"}}, so
+// forms can highlight the offending input; malformed JSON gets a generic message.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	if bodyTooLarge(c, err) {
+		return false
+	}
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"errors": validationFields(obj, validationErrs)}))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{
+			"errors": map[string]string{typeErr.Field: fmt.Sprintf("must be a %s", jsonTypeName(typeErr.Type))},
+		}))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload", "details": err.Error()})
+	}
+	return false
+}
+
+// validationFields maps each failing JSON field to a readable message
+func validationFields(obj interface{}, errs validator.ValidationErrors) map[string]string {
+	fields := map[string]string{}
+	for _, fe := range errs {
+		fields[fe.Field()] = validationMessage(obj, fe)
+	}
+	return fields
+}
+
+// validationMessage turns a failed binding rule into a short human-readable message
+func validationMessage(obj interface{}, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "required"
+	case "gte", "lte":
+		// fields constrained on both sides read better as a range
+		if low, high, ok := rangeBounds(obj, fe.StructField()); ok {
+			return fmt.Sprintf("must be between %s and %s", low, high)
+		}
+		if fe.Tag() == "gte" {
+			return "must be at least " + fe.Param()
+		}
+		return "must be at most " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param() + " characters"
+	default:
+		return "failed " + fe.Tag() + " validation"
+	}
+}
+
+// rangeBounds returns the gte and lte parameters from a struct field's binding tag when both are set
+func rangeBounds(obj interface{}, fieldName string) (string, string, bool) {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", "", false
+	}
+	field, ok := t.FieldByName(fieldName)
+	if !ok {
+		return "", "", false
+	}
+
+	var low, high string
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if value, found := strings.CutPrefix(rule, "gte="); found {
+			low = value
+		}
+		if value, found := strings.CutPrefix(rule, "lte="); found {
+			high = value
+		}
+	}
+	return low, high, low != "" && high != ""
+}
+
+// jsonTypeName describes a Go type in JSON terms for type-mismatch messages
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "valid value"
+	}
+}
+
+// respondInvalidMovie writes a 400 for a prepareMovie failure, per field in the bindJSON
+// error shape, e.g. {"errors":{"rating":"Rating must be between 0 and 5"}}
+func respondInvalidMovie(c *gin.Context, err error) {
+	var fields fieldErrors
+	if errors.As(err, &fields) {
+		c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"errors": fields}))
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// validateMovie runs prepareMovie and the duplicate title check of POST /movies on a payload
+// without saving it, and reports every invalid field at once in the bindJSON error shape.
+// Pass id when validating an edit so the movie's own title doesn't count as a duplicate.
+func validateMovie(c *gin.Context) {
+	id := 0
+	if idStr := c.Query("id"); idStr != "" {
+		var err error
+		if id, err = strconv.Atoi(idStr); err != nil {
+			c.JSON(http.StatusBadRequest, apiError(c, codeInvalidMovieID))
+			return
+		}
+	}
+
+	var movie Movie
+	if !bindJSON(c, &movie) {
+		return
+	}
+
+	fields := fieldErrors{}
+	if err := prepareMovie(&movie); err != nil {
+		if !errors.As(err, &fields) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, invalid := fields["title"]; !invalid {
+		taken, err := titleTakenByOther(db, movie.Title, id)
+		if err != nil {
+			log.Printf("Error checking for duplicate title: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate title", "details": err.Error()})
+			return
+		}
+		if taken {
+			fields["title"] = "Movie with this title already exists"
+		}
+	}
+
+	if len(fields) > 0 {
+		c.JSON(http.StatusBadRequest, apiError(c, codeValidationFailed, gin.H{"errors": fields}))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
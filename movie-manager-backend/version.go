@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// current API version; routes are served under /<apiVersion>
+const apiVersion = "v1"
+
+// injected at build time, e.g.
+// go build -ldflags "-X main.buildVersion=1.4.0 -X main.buildCommit=$(git rev-parse --short HEAD)"
+var (
+	buildVersion = "dev"
+	buildCommit  = "unknown"
+)
+
+// getVersion reports the running build and the API version it serves
+func getVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"version": buildVersion, "commit": buildCommit, "apiVersion": apiVersion})
+}
+
+// deprecatedRouteMiddleware marks responses from the unversioned aliases of the API routes,
+// pointing clients at the versioned path that replaces them
+func deprecatedRouteMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		successor := apiBasePath() + "/" + apiVersion + strings.TrimPrefix(c.Request.URL.Path, apiBasePath())
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
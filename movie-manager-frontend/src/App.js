@@ -28,8 +28,10 @@ const App = () => {
   const [totalPages, setTotalPages] = useState(1);
   const [message, setMessage] = useState(''); 
   const [isMessageError, setIsMessageError] = useState(false);
+  // highest rating the backend accepts, advertised in its X-Rating-Scale header
+  const [ratingScale, setRatingScale] = useState(5);
 
-  const API_BASE_URL = 'http://localhost:8080';
+  const API_BASE_URL = 'http://localhost:8080/v1';
 
   // Memoized fetchMovies
   const fetchMovies = useCallback(async () => {
@@ -47,6 +49,8 @@ const App = () => {
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`);
       }
+      const scale = parseInt(response.headers.get('X-Rating-Scale'));
+      if (scale > 0) setRatingScale(scale);
       const data = await response.json();
       setMovies(data.movies);
       setTotalPages(data.totalPages);
@@ -62,7 +66,7 @@ const App = () => {
     fetchMovies();
   }, [fetchMovies]);
 
-  // Handle movie creation or update
+  // Handle movie creation or update; returns the server's per-field errors when it rejects the movie
   const handleSaveMovie = async (movieData) => {
     setMessage('');
     setIsMessageError(false);
@@ -73,7 +77,7 @@ const App = () => {
         response = await fetch(`${API_BASE_URL}/movies/${editingMovie.id}`, {
           method: 'PUT',
           headers: { 'Content-Type': 'application/json' },
-          body: JSON.stringify(movieData),
+          body: JSON.stringify({ ...movieData, version: editingMovie.version }),
         });
       } else {
         // Create
@@ -86,6 +90,9 @@ const App = () => {
 
       const data = await response.json();
       if (!response.ok) {
+        if (data.errors) {
+          return data.errors;
+        }
         throw new Error(data.error || 'Failed to save movie.');
       }
 
@@ -235,6 +242,7 @@ const App = () => {
         {showForm ? (
           <MovieForm
             movie={editingMovie}
+            ratingScale={ratingScale}
             onSave={handleSaveMovie}
             onCancel={handleCloseForm}
           />
@@ -251,6 +259,7 @@ const App = () => {
                 <MovieCard
                   key={movie.id}
                   movie={movie}
+                  ratingScale={ratingScale}
                   onEdit={handleEditClick}
                   onDelete={handleDeleteMovie}
                 />
@@ -297,7 +306,7 @@ const App = () => {
 };
 
 // MovieCard Component
-const MovieCard = ({ movie, onEdit, onDelete }) => {
+const MovieCard = ({ movie, ratingScale, onEdit, onDelete }) => {
   return (
     <div className="bg-white border border-gray-200 rounded-lg shadow-md overflow-hidden transform transition duration-300 hover:scale-103 hover:shadow-lg">
       <div className="p-5">
@@ -335,9 +344,9 @@ const MovieCard = ({ movie, onEdit, onDelete }) => {
           <span className="font-medium">Year:</span> {movie.year} 
         </p>
         <div className="flex items-center">
-          <span className="font-medium text-gray-700 mr-2">Rating: {movie.rating}</span>
-          {[...Array(5)].map((_, i) => ( 
-            <StarIcon key={i} filled={i < movie.rating} />
+          <span className="font-medium text-gray-700 mr-2">Rating: {movie.averageRating ?? 'Not yet rated'}</span>
+          {[...Array(ratingScale)].map((_, i) => ( 
+            <StarIcon key={i} filled={i < Math.round(movie.averageRating ?? 0)} />
           ))}
         </div>
       </div>
@@ -346,11 +355,11 @@ const MovieCard = ({ movie, onEdit, onDelete }) => {
 };
 
 // MovieForm Component for creating/updating movies
-const MovieForm = ({ movie, onSave, onCancel }) => {
+const MovieForm = ({ movie, ratingScale, onSave, onCancel }) => {
   const [title, setTitle] = useState(movie ? movie.title : ''); 
   const [genre, setGenre] = useState(movie ? movie.genre : ''); 
   const [year, setYear] = useState(movie ? movie.year : '');     
-  const [rating, setRating] = useState(movie ? movie.rating : 0);
+  const [rating, setRating] = useState(movie && movie.rating != null ? movie.rating : '');
   const [formErrors, setFormErrors] = useState({});
 
   const validateForm = () => {
@@ -362,17 +371,22 @@ const MovieForm = ({ movie, onSave, onCancel }) => {
     if (!year || isNaN(year) || year < 1900 || year > currentYear) {
       errors.year = `Year must be between 1900 and ${currentYear}.`;
     }
-    if (rating < 0 || rating > 5) {
-      errors.rating = 'Rating must be between 0 and 5.';
+    if (rating !== '' && (rating < 0 || rating > ratingScale)) {
+      errors.rating = `Rating must be between 0 and ${ratingScale}.`;
     }
     setFormErrors(errors);
     return Object.keys(errors).length === 0;
   };
 
-  const handleSubmit = (e) => {
+  const handleSubmit = async (e) => {
     e.preventDefault();
     if (validateForm()) {
-      onSave({ title, genre, year: parseInt(year), rating: parseInt(rating) });
+      // an empty rating leaves the movie unrated (and clears an existing rating when editing)
+      const ratingFields = rating === '' ? (movie ? { clearRating: true } : {}) : { rating: parseInt(rating) };
+      const serverErrors = await onSave({ title, genre, year: parseInt(year), ...ratingFields });
+      if (serverErrors) {
+        setFormErrors(serverErrors);
+      }
     }
   };
 
@@ -424,7 +438,7 @@ const MovieForm = ({ movie, onSave, onCancel }) => {
         </div>
         <div>
           <label htmlFor="rating" className="block text-sm font-medium text-gray-700">
-            Rating (0-5)
+            Rating (0-{ratingScale})
           </label>
           <input
             type="number"
@@ -432,9 +446,9 @@ const MovieForm = ({ movie, onSave, onCancel }) => {
             value={rating}
             onChange={(e) => setRating(e.target.value)}
             min="0"
-            max="5"
+            max={ratingScale}
+            placeholder="Not yet rated"
             className="mt-1 block w-full p-2 border border-gray-300 rounded-md shadow-sm focus:ring-blue-500 focus:border-blue-500"
-            required
           />
           {formErrors.rating && <p className="text-red-500 text-xs mt-1">{formErrors.rating}</p>}
         </div>